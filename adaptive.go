@@ -0,0 +1,67 @@
+package scattergather
+
+import (
+	"sync"
+	"time"
+)
+
+// Adjust the maximum parallelism between min and max based on how tasks fare,
+// to find the best parallelism for a backend that slows down or fails when
+// overloaded. Every time as many tasks have succeeded within latency as the
+// current maximum parallelism, the maximum is increased by one. When a task
+// fails or takes longer than latency, the maximum is halved. Tasks that were
+// started before the last decrease do not cause another decrease. A latency
+// of 0 only takes failures into account. The parallelism starts at the
+// current maximum, limited to min and max. A max of 0 disables adjustment.
+func (sg *ScatterGather[T]) SetAdaptiveParallelism(min, max int64, latency time.Duration) {
+	if max == 0 {
+		sg.adaptive = nil
+		return
+	}
+	sg.init(0)
+	sg.queueLock.Lock()
+	parallel := sg.parallel
+	sg.queueLock.Unlock()
+	parallel = clamp(parallel, min, max)
+	sg.adaptive = &aimd{min: min, max: max, latency: latency, parallel: parallel}
+	sg.SetParallel(parallel)
+}
+
+// An additive-increase/multiplicative-decrease controller for the maximum
+// parallelism
+type aimd struct {
+	lock      sync.Mutex
+	min       int64
+	max       int64
+	latency   time.Duration
+	parallel  int64
+	successes int64
+	decreased time.Time
+}
+
+// Record the outcome of a task that was started at the given time, and return
+// the new maximum parallelism if it should change
+func (a *aimd) record(started time.Time, failed bool) (int64, bool) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if !failed && (a.latency == 0 || time.Since(started) <= a.latency) {
+		a.successes++
+		if a.successes < a.parallel || a.parallel >= a.max {
+			return a.parallel, false
+		}
+		a.successes = 0
+		a.parallel++
+		return a.parallel, true
+	}
+	if started.Before(a.decreased) || a.parallel <= a.min {
+		return a.parallel, false
+	}
+	a.successes = 0
+	a.decreased = time.Now()
+	a.parallel = clamp(a.parallel/2, a.min, a.max)
+	return a.parallel, true
+}
+
+func clamp(value, low, high int64) int64 {
+	return min(max(value, low), high)
+}
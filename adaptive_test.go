@@ -0,0 +1,44 @@
+package scattergather
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAIMD(t *testing.T) {
+	a := &aimd{min: 2, max: 5, latency: time.Second, parallel: 3}
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		_, changed := a.record(now, false)
+		assert.False(t, changed, "Parallelism is not increased before enough tasks succeed")
+	}
+	parallel, changed := a.record(now, false)
+	assert.True(t, changed)
+	assert.Equal(t, int64(4), parallel, "Parallelism is increased by one")
+	parallel, changed = a.record(now.Add(-2*time.Second), false)
+	assert.True(t, changed)
+	assert.Equal(t, int64(2), parallel, "Parallelism is halved for slow tasks")
+	a.parallel = 5
+	_, changed = a.record(now, true)
+	assert.False(t, changed, "Tasks started before a decrease do not cause another decrease")
+	parallel, changed = a.record(time.Now(), true)
+	assert.True(t, changed)
+	assert.Equal(t, int64(2), parallel, "Parallelism is halved for failed tasks, but not below the minimum")
+	for i := 0; i < 100; i++ {
+		parallel, _ = a.record(time.Now(), false)
+	}
+	assert.Equal(t, int64(5), parallel, "Parallelism is not increased beyond the maximum")
+}
+
+func TestSetAdaptiveParallelism(t *testing.T) {
+	sg := New[int](100)
+	sg.SetAdaptiveParallelism(1, 8, 0)
+	assert.Equal(t, int64(8), sg.parallel, "Parallelism is limited to the maximum")
+	ctx := context.Background()
+	sg.Run(ctx, squareOdds(2))
+	sg.Wait()
+	assert.Equal(t, int64(4), sg.parallel, "Failures decrease parallelism")
+}
@@ -0,0 +1,78 @@
+package scattergather
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Pause starting new tasks when too many recent tasks failed. The outcomes of
+// the last window tasks are tracked, and when the fraction of failures among
+// them reaches threshold, no new tasks are started until cooldown has passed.
+// Tasks that are already running are not affected. After the cool-down period,
+// tasks are started again and the failure rate is measured anew. This must be
+// set before the first task is submitted. A window of 0 disables the circuit
+// breaker.
+func (sg *ScatterGather[T]) SetCircuitBreaker(window int, threshold float64, cooldown time.Duration) {
+	if window == 0 {
+		sg.breaker = nil
+		return
+	}
+	sg.breaker = &circuitBreaker{outcomes: make([]bool, window), threshold: threshold, cooldown: cooldown}
+}
+
+type circuitBreaker struct {
+	lock      sync.Mutex
+	outcomes  []bool
+	next      int
+	count     int
+	failures  int
+	threshold float64
+	cooldown  time.Duration
+	openUntil time.Time
+}
+
+// Record the outcome of a task, and trip the breaker if the failure rate is
+// too high
+func (cb *circuitBreaker) record(failed bool) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	if cb.count == len(cb.outcomes) && cb.outcomes[cb.next] {
+		cb.failures--
+	}
+	cb.outcomes[cb.next] = failed
+	cb.next = (cb.next + 1) % len(cb.outcomes)
+	cb.count = min(cb.count+1, len(cb.outcomes))
+	if failed {
+		cb.failures++
+	}
+	if cb.count == len(cb.outcomes) && float64(cb.failures)/float64(cb.count) >= cb.threshold {
+		cb.openUntil = time.Now().Add(cb.cooldown)
+		cb.next, cb.count, cb.failures = 0, 0, 0
+	}
+}
+
+// Whether the breaker is open, so no new tasks may start
+func (cb *circuitBreaker) open() bool {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	return time.Now().Before(cb.openUntil)
+}
+
+// Wait until the breaker is closed, or ctx is done
+func (cb *circuitBreaker) wait(ctx context.Context) error {
+	cb.lock.Lock()
+	delay := time.Until(cb.openUntil)
+	cb.lock.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,30 @@
+package scattergather
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	sg := New[time.Time](1)
+	sg.UseWorkerPool(true)
+	sg.SetCircuitBreaker(2, 0.5, 500*time.Millisecond)
+	sg.KeepAllResults(true)
+	sg.OrderedResults(true)
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		sg.Run(ctx, func() (time.Time, error) {
+			if i == 1 {
+				return time.Now(), &cantEven{}
+			}
+			return time.Now(), nil
+		})
+	}
+	results, _ := sg.Wait()
+	assert.Less(t, results[1].Sub(results[0]), 100*time.Millisecond, "Tasks run without delay")
+	assert.GreaterOrEqual(t, results[2].Sub(results[1]), 500*time.Millisecond, "The circuit breaker trips after too many failures")
+	assert.Less(t, results[3].Sub(results[2]), 100*time.Millisecond, "Tasks run without delay after the cool-down")
+}
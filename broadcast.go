@@ -0,0 +1,27 @@
+package scattergather
+
+import (
+	"context"
+)
+
+// Run the same input through all functions in fns in parallel, with at most
+// parallel functions running at the same time, and return their results keyed
+// by the name of the function. When parallel is 0, the maximum is set to
+// GOMAXPROCS. Functions that fail have no entry in the returned map, their
+// errors are aggregated as Wait() does, labelled with the function's name.
+func Broadcast[In, Out any](ctx context.Context, parallel int64, input In, fns map[string]func(context.Context, In) (Out, error)) (map[string]Out, error) {
+	sg := New[Out](parallel)
+	for name, fn := range fns {
+		sg.RunNamed(ctx, name, func(ctx context.Context) (Out, error) {
+			return fn(ctx, input)
+		})
+	}
+	results, err := sg.WaitResults()
+	values := make(map[string]Out, len(results))
+	for _, res := range results {
+		if res.Err == nil {
+			values[res.Label] = res.Value
+		}
+	}
+	return values, err
+}
@@ -0,0 +1,24 @@
+package scattergather
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroadcast(t *testing.T) {
+	fns := map[string]func(context.Context, string) (string, error){
+		"upper": func(ctx context.Context, s string) (string, error) { return strings.ToUpper(s), nil },
+		"twice": func(ctx context.Context, s string) (string, error) { return strings.Repeat(s, 2), nil },
+		"broken": func(ctx context.Context, s string) (string, error) {
+			return "", errors.New("broken")
+		},
+	}
+	result, err := Broadcast(context.Background(), 0, "hello", fns)
+	assert.Equal(t, map[string]string{"upper": "HELLO", "twice": "hellohello"}, result, "Results are keyed by function name")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "broken: broken", "Errors are labelled with the function name")
+}
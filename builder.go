@@ -0,0 +1,104 @@
+package scattergather
+
+import (
+	"time"
+)
+
+// A Builder collects the configuration of a ScatterGather in one chain of
+// calls. Builders are immutable: every method returns a new Builder, so a
+// partially configured Builder can be shared and extended in different ways.
+// Build creates a new ScatterGather object with the configuration every time it
+// is called.
+type Builder[T any] struct {
+	parallel int64
+	options  []func(*ScatterGather[T])
+}
+
+// Create a new Builder for ScatterGather objects that run at most GOMAXPROCS
+// tasks in parallel.
+func NewBuilder[T any]() Builder[T] {
+	return Builder[T]{}
+}
+
+// Create a new ScatterGather object with this configuration
+func (b Builder[T]) Build() *ScatterGather[T] {
+	sg := New[T](b.parallel)
+	for _, option := range b.options {
+		option(sg)
+	}
+	return sg
+}
+
+// Apply any configuration that has no method of its own, such as
+// func(sg *ScatterGather[T]) { sg.SetKeyLimit(2) }
+func (b Builder[T]) With(option func(*ScatterGather[T])) Builder[T] {
+	b.options = append(b.options[:len(b.options):len(b.options)], option)
+	return b
+}
+
+// Run at most parallel tasks in parallel, see New
+func (b Builder[T]) WithParallel(parallel int64) Builder[T] {
+	b.parallel = parallel
+	return b
+}
+
+// See SetRetry
+func (b Builder[T]) WithRetry(retries int, backoff time.Duration) Builder[T] {
+	return b.With(func(sg *ScatterGather[T]) { sg.SetRetry(retries, backoff) })
+}
+
+// See SetTaskTimeout
+func (b Builder[T]) WithTimeout(timeout time.Duration) Builder[T] {
+	return b.With(func(sg *ScatterGather[T]) { sg.SetTaskTimeout(timeout) })
+}
+
+// See SetRateLimit
+func (b Builder[T]) WithRateLimit(limiter RateLimiter) Builder[T] {
+	return b.With(func(sg *ScatterGather[T]) { sg.SetRateLimit(limiter) })
+}
+
+// See SetGatherer. As gatherers keep the results of a batch, every object that
+// is built gets a gatherer of its own, created by calling newGatherer.
+func (b Builder[T]) WithGatherer(newGatherer func() Gatherer[T]) Builder[T] {
+	return b.With(func(sg *ScatterGather[T]) { sg.SetGatherer(newGatherer()) })
+}
+
+// See SetErrorClassifier
+func (b Builder[T]) WithErrorClassifier(classifier func(error) ErrorClass) Builder[T] {
+	return b.With(func(sg *ScatterGather[T]) { sg.SetErrorClassifier(classifier) })
+}
+
+// See SetCircuitBreaker
+func (b Builder[T]) WithCircuitBreaker(window int, threshold float64, cooldown time.Duration) Builder[T] {
+	return b.With(func(sg *ScatterGather[T]) { sg.SetCircuitBreaker(window, threshold, cooldown) })
+}
+
+// See SetHedgeDelay
+func (b Builder[T]) WithHedgeDelay(delay time.Duration) Builder[T] {
+	return b.With(func(sg *ScatterGather[T]) { sg.SetHedgeDelay(delay) })
+}
+
+// See SetMaxPending
+func (b Builder[T]) WithMaxPending(pending int64) Builder[T] {
+	return b.With(func(sg *ScatterGather[T]) { sg.SetMaxPending(pending) })
+}
+
+// See SetCache
+func (b Builder[T]) WithCache(cache Cache[T]) Builder[T] {
+	return b.With(func(sg *ScatterGather[T]) { sg.SetCache(cache) })
+}
+
+// See UseWorkerPool
+func (b Builder[T]) WithWorkerPool() Builder[T] {
+	return b.With(func(sg *ScatterGather[T]) { sg.UseWorkerPool(true) })
+}
+
+// See FailFast
+func (b Builder[T]) WithFailFast() Builder[T] {
+	return b.With(func(sg *ScatterGather[T]) { sg.FailFast(true) })
+}
+
+// See RecoverPanics
+func (b Builder[T]) WithRecoverPanics() Builder[T] {
+	return b.With(func(sg *ScatterGather[T]) { sg.RecoverPanics(true) })
+}
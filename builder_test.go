@@ -0,0 +1,40 @@
+package scattergather
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder(t *testing.T) {
+	base := NewBuilder[int]().WithParallel(2).WithRetry(2, time.Millisecond)
+	withGatherer := base.WithGatherer(func() Gatherer[int] {
+		return NewTopK(1, func(a, b int) bool { return a < b })
+	})
+	failFast := base.WithFailFast()
+
+	sg := withGatherer.Build()
+	assert.Equal(t, int64(2), sg.semaphore.Size(), "The parallelism is set")
+	var calls atomic.Int32
+	sg.Run(context.Background(), func() (int, error) {
+		if calls.Add(1) < 3 {
+			return 0, errors.New("flaky")
+		}
+		return 5, nil
+	})
+	sg.Run(context.Background(), func() (int, error) { return 9, nil })
+	_, err := sg.Wait()
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), calls.Load(), "Retries are configured")
+	topK := sg.gatherer.(*TopK[int])
+	assert.Equal(t, []int{9}, topK.Results(), "The gatherer is configured")
+	assert.NotSame(t, topK, withGatherer.Build().gatherer, "Every object gets a gatherer of its own")
+
+	assert.False(t, base.Build().failFast, "Extending a builder does not change it")
+	assert.True(t, failFast.Build().failFast)
+	assert.Nil(t, failFast.Build().gatherer, "Builders derived from the same builder are independent")
+}
@@ -0,0 +1,81 @@
+package scattergather
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A cache of task results by key, which outlives batches. Implementations
+// must be safe for concurrent use.
+type Cache[T any] interface {
+	Get(key string) (T, bool)
+	Set(key string, value T)
+}
+
+// Use cache for tasks submitted with RunCached. This must be set before the
+// first task is submitted. A nil cache disables caching.
+func (sg *ScatterGather[T]) SetCache(cache Cache[T]) {
+	sg.cache = cache
+}
+
+// Add a piece of work to be run, like RunContext, unless the cache set with
+// SetCache has a result for key. In that case the cached result is gathered
+// as the result of the task, without running it or occupying a slot.
+// Successful results of tasks that do run are stored in the cache. Tasks with
+// the same key that are submitted before the first of them has finished all
+// run.
+func (sg *ScatterGather[T]) RunCached(ctx context.Context, key string, callable func(context.Context) (T, error)) *Task[T] {
+	sg.init(0)
+	if sg.cache == nil {
+		return sg.RunContext(ctx, callable)
+	}
+	if value, ok := sg.cache.Get(key); ok {
+		t := &task[T]{ctx: ctx, weight: 1}
+		t.handle = newTask(t)
+		sg.admit(t)
+		go sg.finish(t, value, nil)
+		return t.handle
+	}
+	return sg.RunContext(ctx, func(ctx context.Context) (T, error) {
+		value, err := callable(ctx)
+		if err == nil {
+			sg.cache.Set(key, value)
+		}
+		return value, err
+	})
+}
+
+// Create a Cache that keeps results for ttl after they were stored
+func NewTTLCache[T any](ttl time.Duration) Cache[T] {
+	return &ttlCache[T]{ttl: ttl, entries: make(map[string]ttlEntry[T])}
+}
+
+type ttlCache[T any] struct {
+	lock    sync.Mutex
+	ttl     time.Duration
+	entries map[string]ttlEntry[T]
+}
+
+type ttlEntry[T any] struct {
+	value   T
+	expires time.Time
+}
+
+func (c *ttlCache[T]) Get(key string) (T, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entry, ok := c.entries[key]
+	if ok && time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		var zero T
+		return zero, false
+	}
+	return entry.value, ok
+}
+
+func (c *ttlCache[T]) Set(key string, value T) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries[key] = ttlEntry[T]{value: value, expires: time.Now().Add(c.ttl)}
+}
@@ -0,0 +1,65 @@
+package scattergather
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	sg := New[int](1)
+	sg.SetCache(NewTTLCache[int](time.Hour))
+	var calls atomic.Int32
+	gathered := make(chan int, 10)
+	sg.OnResult(func(n int) { gathered <- n })
+	ctx := context.Background()
+	submit := func(n int) {
+		sg.RunCached(ctx, string(rune('a'+n)), func(ctx context.Context) (int, error) {
+			calls.Add(1)
+			return n * n, nil
+		})
+	}
+	for i := 0; i < 3; i++ {
+		submit(i)
+	}
+	results, err := sg.Wait()
+	assert.Nil(t, err)
+	sort.Ints(results)
+	assert.Equal(t, int32(3), calls.Load())
+	for range results {
+		<-gathered
+	}
+
+	sg.Reset()
+	// Hold the only slot, so only cached results can be gathered
+	release := make(chan struct{})
+	sg.Run(ctx, func() (int, error) { <-release; return -1, nil })
+	for i := 0; i < 3; i++ {
+		submit(i)
+	}
+	var cached []int
+	for i := 0; i < 3; i++ {
+		cached = append(cached, <-gathered)
+	}
+	close(release)
+	_, err = sg.Wait()
+	assert.Nil(t, err)
+	sort.Ints(cached)
+	assert.Equal(t, results, cached, "Cached results are returned without occupying a slot")
+	assert.Equal(t, int32(3), calls.Load(), "Cached tasks are not run again")
+}
+
+func TestTTLCache(t *testing.T) {
+	cache := NewTTLCache[string](50 * time.Millisecond)
+	cache.Set("key", "value")
+	value, ok := cache.Get("key")
+	assert.True(t, ok)
+	assert.Equal(t, "value", value)
+	time.Sleep(60 * time.Millisecond)
+	_, ok = cache.Get("key")
+	assert.False(t, ok, "Entries expire after the ttl")
+}
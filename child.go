@@ -0,0 +1,26 @@
+package scattergather
+
+// Create a new ScatterGather object that shares the slots of parent, so tasks
+// of the parent and of all its children together run at most as many tasks in
+// parallel as the parent allows. This keeps nested fan-outs under a single
+// limit instead of multiplying it. Children have their own configuration,
+// results and errors, and their result type may differ from the parent's.
+//
+// Changing the parallelism of the parent or of any child changes it for all of
+// them. A task that waits for tasks of a child should do so using the child's
+// Await, passing its own context, so it gives up its slot while it waits.
+func NewChild[U, T any](parent *ScatterGather[T]) *ScatterGather[U] {
+	parent.init(0)
+	parent.queueLock.Lock()
+	parallel := parent.parallel
+	parent.queueLock.Unlock()
+	child := New[U](parallel)
+	child.semaphore = parent.semaphore
+	return child
+}
+
+// Create a new ScatterGather object with the same result type that shares the
+// slots of sg, as NewChild does.
+func (sg *ScatterGather[T]) Child() *ScatterGather[T] {
+	return NewChild[T](sg)
+}
@@ -0,0 +1,63 @@
+package scattergather
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChild(t *testing.T) {
+	parent := New[int](2)
+	child := NewChild[string](parent)
+	var running, peak atomic.Int64
+	enter := func() {
+		n := running.Add(1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+	}
+	for i := 0; i < 5; i++ {
+		parent.Run(context.Background(), func() (int, error) {
+			enter()
+			return i, nil
+		})
+		child.Run(context.Background(), func() (string, error) {
+			enter()
+			return strconv.Itoa(i), nil
+		})
+	}
+	ints, err := parent.Wait()
+	assert.Nil(t, err)
+	assert.Len(t, ints, 5)
+	strs, err := child.Wait()
+	assert.Nil(t, err)
+	assert.Len(t, strs, 5)
+	assert.LessOrEqual(t, peak.Load(), int64(2), "Parent and child share their slots")
+}
+
+func TestChildAwait(t *testing.T) {
+	parent := New[int](1)
+	task := parent.RunContext(context.Background(), func(ctx context.Context) (int, error) {
+		child := NewChild[string](parent)
+		tasks := []*Task[string]{}
+		for _, s := range []string{"1", "2", "3"} {
+			tasks = append(tasks, child.Run(ctx, func() (string, error) { return s + s, nil }))
+		}
+		values, err := child.Await(ctx, tasks...)
+		total := 0
+		for _, v := range values {
+			n, _ := strconv.Atoi(v)
+			total += n
+		}
+		return total, err
+	})
+	_, err := parent.Wait()
+	assert.Nil(t, err)
+	value, _ := task.Result()
+	assert.Equal(t, 66, value, "A task can wait for a child without holding its slot")
+}
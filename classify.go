@@ -0,0 +1,41 @@
+package scattergather
+
+import (
+	"errors"
+)
+
+// How an error returned by a task should be handled
+type ErrorClass int
+
+const (
+	// Record the error, and return it from Wait()
+	ErrorRecord ErrorClass = iota
+	// Retry the task, within the limits set by SetRetry, and record the
+	// error if it keeps failing
+	ErrorRetry
+	// Record the error, and cancel all other tasks as in fail-fast mode
+	ErrorAbort
+	// Silently drop the error. The task produces no result either.
+	ErrorIgnore
+)
+
+// Set a function that decides how to handle each error returned by a task.
+// Without a classifier, all errors are retried when SetRetry is used, and
+// recorded otherwise. ErrSkip is always ignored, and not passed to the
+// classifier.
+func (sg *ScatterGather[T]) SetErrorClassifier(classifier func(error) ErrorClass) {
+	sg.classifier = classifier
+}
+
+func (sg *ScatterGather[T]) classify(err error) ErrorClass {
+	if errors.Is(err, ErrSkip) {
+		return ErrorIgnore
+	}
+	if sg.classifier == nil {
+		if sg.retries > 0 {
+			return ErrorRetry
+		}
+		return ErrorRecord
+	}
+	return sg.classifier(err)
+}
@@ -0,0 +1,93 @@
+package scattergather
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	errRetry  = errors.New("retry")
+	errAbort  = errors.New("abort")
+	errIgnore = errors.New("ignore")
+)
+
+func classifier(err error) ErrorClass {
+	switch {
+	case errors.Is(err, errRetry):
+		return ErrorRetry
+	case errors.Is(err, errAbort):
+		return ErrorAbort
+	case errors.Is(err, errIgnore):
+		return ErrorIgnore
+	}
+	return ErrorRecord
+}
+
+func TestErrorClassifier(t *testing.T) {
+	sg := New[int](0)
+	sg.SetErrorClassifier(classifier)
+	sg.SetRetry(3, time.Millisecond)
+	ctx := context.Background()
+	var retries, records atomic.Int32
+	sg.Run(ctx, func() (int, error) {
+		if retries.Add(1) < 3 {
+			return 0, errRetry
+		}
+		return 1, nil
+	})
+	sg.Run(ctx, func() (int, error) {
+		records.Add(1)
+		return 0, &cantEven{}
+	})
+	sg.Run(ctx, func() (int, error) { return 0, errIgnore })
+	sg.Run(ctx, square(2))
+	results, err := sg.Wait()
+	sort.Ints(results)
+	assert.Equal(t, []int{1, 4}, results, "Retried tasks succeed, ignored tasks produce no result")
+	assert.Equal(t, int32(3), retries.Load(), "Retryable errors are retried")
+	assert.Equal(t, int32(1), records.Load(), "Other errors are not retried")
+	assert.ErrorIs(t, err, &ScatteredError{Errors: []error{&cantEven{}}}, "Ignored errors are dropped")
+}
+
+func TestErrorClassifierAbort(t *testing.T) {
+	sg := New[int](10)
+	sg.SetErrorClassifier(classifier)
+	ctx := context.Background()
+	sg.Run(ctx, func() (int, error) { return 0, errAbort })
+	for i := 0; i < 5; i++ {
+		sg.RunContext(ctx, func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		})
+	}
+	_, err := sg.Wait()
+	assert.ErrorIs(t, err, errAbort, "The aborting error is recorded")
+	assert.Equal(t, 6, len(err.(*ScatteredError).Errors), "All other tasks are cancelled")
+}
+
+func TestErrSkip(t *testing.T) {
+	sg := New[int](0)
+	sg.SetRetry(3, time.Millisecond)
+	ctx := context.Background()
+	var calls atomic.Int32
+	for i := 0; i < 10; i++ {
+		sg.Run(ctx, func() (int, error) {
+			calls.Add(1)
+			if i%2 == 0 {
+				return 0, ErrSkip
+			}
+			return i, nil
+		})
+	}
+	res, err := sg.Wait()
+	sort.Ints(res)
+	assert.Nil(t, err, "Skipped tasks do not fail")
+	assert.Equal(t, []int{1, 3, 5, 7, 9}, res, "Skipped tasks produce no result")
+	assert.Equal(t, int32(10), calls.Load(), "Skipped tasks are not retried")
+}
@@ -0,0 +1,48 @@
+package scattergather
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Anything that can encode values to a stream, such as a *json.Encoder or a
+// *gob.Encoder
+type Encoder interface {
+	Encode(v any) error
+}
+
+// A Gatherer that encodes values as they arrive, instead of collecting them,
+// so command line tools can emit output immediately. Count is the number of
+// values encoded.
+type Emitter[T any] struct {
+	Count   int
+	encoder Encoder
+	err     error
+}
+
+// Create a new Emitter that writes values to w as JSON, one value per line
+func NewEmitter[T any](w io.Writer) *Emitter[T] {
+	return NewEncoderEmitter[T](json.NewEncoder(w))
+}
+
+// Create a new Emitter that encodes values with encoder
+func NewEncoderEmitter[T any](encoder Encoder) *Emitter[T] {
+	return &Emitter[T]{encoder: encoder}
+}
+
+func (e *Emitter[T]) OnResult(value T) {
+	if e.err != nil {
+		return
+	}
+	if e.err = e.encoder.Encode(value); e.err == nil {
+		e.Count++
+	}
+}
+
+func (e *Emitter[T]) OnError(error) {}
+
+// Returns the first error that occurred while encoding values. No more values
+// are encoded after such an error.
+func (e *Emitter[T]) Finish() error {
+	return e.err
+}
@@ -0,0 +1,44 @@
+package scattergather
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingEncoder struct{}
+
+func (failingEncoder) Encode(any) error {
+	return errors.New("disk full")
+}
+
+func TestEmitter(t *testing.T) {
+	sg := New[int](0)
+	buf := &bytes.Buffer{}
+	emitter := NewEmitter[int](buf)
+	sg.SetGatherer(emitter)
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		sg.Run(ctx, squareOdds(i))
+	}
+	_, err := sg.Wait()
+	assert.Equal(t, 5, len(err.(*ScatteredError).Errors), "Errors are returned")
+	assert.Equal(t, 5, emitter.Count, "All values are encoded")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.ElementsMatch(t, []string{"1", "9", "25", "49", "81"}, lines, "Values are written as JSON lines")
+
+	sg = New[int](0)
+	emitter = NewEncoderEmitter[int](failingEncoder{})
+	sg.SetGatherer(emitter)
+	for i := 0; i < 10; i++ {
+		sg.Run(ctx, square(i))
+	}
+	_, err = sg.Wait()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "disk full", "Encoding errors are returned")
+	assert.Equal(t, 0, emitter.Count)
+}
@@ -0,0 +1,78 @@
+package scattergather
+
+import (
+	"context"
+	"errors"
+)
+
+// An adapter with the API of errgroup.Group from golang.org/x/sync/errgroup,
+// so code written against errgroup can move to ScatterGather one call site at
+// a time. Unlike errgroup, the limit can be changed while tasks are running,
+// Go does not block when the limit is reached, and tasks that produce a value
+// can be submitted with Run. Their values are available from Snapshot() once
+// Wait() has returned.
+type ErrGroup[T any] struct {
+	*ScatterGather[T]
+}
+
+// Create a new ErrGroup object without a limit on the number of tasks that run
+// in parallel, like a zero errgroup.Group.
+func NewErrGroup[T any]() *ErrGroup[T] {
+	return &ErrGroup[T]{ScatterGather: New[T](Unlimited)}
+}
+
+// Create a new ErrGroup object and a context derived from ctx, which is
+// cancelled when the first task fails or when Wait returns, like
+// errgroup.WithContext.
+func ErrGroupWithContext[T any](ctx context.Context) (*ErrGroup[T], context.Context) {
+	sg, ctx := WithContext[T](ctx, Unlimited)
+	return &ErrGroup[T]{ScatterGather: sg}, ctx
+}
+
+// Limit the number of tasks that run in parallel to n. A negative n removes
+// the limit. Unlike errgroup, this may be called while tasks are running, and
+// an n of 0 sets the limit to GOMAXPROCS.
+func (g *ErrGroup[T]) SetLimit(n int) {
+	if n < 0 {
+		g.SetParallel(Unlimited)
+	} else {
+		g.SetParallel(int64(n))
+	}
+}
+
+// Call f in a separate goroutine, once the limit allows it. The first error
+// returned by any such function is returned by Wait().
+func (g *ErrGroup[T]) Go(f func() error) {
+	g.Run(context.Background(), g.wrap(f))
+}
+
+// Call f in a separate goroutine, but only if the limit allows it to start
+// immediately. Returns whether f was started.
+func (g *ErrGroup[T]) TryGo(f func() error) bool {
+	return g.TryRun(context.Background(), g.wrap(f))
+}
+
+// Tasks submitted with Go have no result
+func (g *ErrGroup[T]) wrap(f func() error) func() (T, error) {
+	return func() (ret T, err error) {
+		if err = f(); err == nil {
+			err = ErrSkip
+		}
+		return ret, err
+	}
+}
+
+// Wait for all tasks to complete, and return the first error returned by any
+// of them as it was returned, like errgroup does. Use the embedded
+// ScatterGather's Err() to get all errors.
+func (g *ErrGroup[T]) Wait() error {
+	_, err := g.ScatterGather.Wait()
+	var errs *ScatteredError
+	if !errors.As(err, &errs) || len(errs.Errors) == 0 {
+		return err
+	}
+	if err, ok := errs.Errors[0].(*TaskError); ok {
+		return err.Err
+	}
+	return errs.Errors[0]
+}
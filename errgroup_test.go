@@ -0,0 +1,37 @@
+package scattergather
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrGroup(t *testing.T) {
+	g := NewErrGroup[int]()
+	g.SetLimit(2)
+	var ran atomic.Int32
+	for i := 0; i < 5; i++ {
+		g.Go(func() error {
+			ran.Add(1)
+			return nil
+		})
+	}
+	g.Run(context.Background(), func() (int, error) { return 42, nil })
+	assert.Nil(t, g.Wait())
+	assert.Equal(t, int32(5), ran.Load(), "All functions are called")
+	assert.Equal(t, []int{42}, g.Snapshot(), "Only tasks submitted with Run have results")
+
+	g, ctx := ErrGroupWithContext[int](context.Background())
+	g.Go(func() error { return io.EOF })
+	g.Go(func() error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	err := g.Wait()
+	assert.True(t, err == io.EOF, "The first error is returned as is")
+	assert.True(t, errors.Is(g.Err(), context.Canceled), "All errors are available from Err()")
+}
@@ -0,0 +1,90 @@
+package scattergather
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/seveas/scattergather/semaphore"
+)
+
+// The context key under which a running task is recorded in the context
+// passed to its callable
+type runningKey struct{}
+
+// A task that is running, recorded in the context passed to its callable so
+// subtasks submitted from within it can be recognized
+type runningTask struct {
+	owner     any
+	semaphore *semaphore.Weighted
+	weight    int64
+	park      func(bool)
+	running   atomic.Bool
+}
+
+// Record a task that is about to run in the context passed to its callable
+func (sg *ScatterGather[T]) markRunning(ctx context.Context, t *task[T]) (context.Context, *runningTask) {
+	rt := &runningTask{owner: sg, semaphore: sg.semaphore, weight: t.weight, park: sg.parkWorker}
+	rt.running.Store(true)
+	return context.WithValue(ctx, runningKey{}, rt), rt
+}
+
+// Return the task of this ScatterGather that is running with ctx, or nil if
+// ctx does not belong to a running task.
+func (sg *ScatterGather[T]) runningTask(ctx context.Context) *runningTask {
+	if rt, ok := ctx.Value(runningKey{}).(*runningTask); ok && rt.owner == sg && rt.running.Load() {
+		return rt
+	}
+	return nil
+}
+
+// Return the running task that ctx belongs to if it holds a slot of this
+// ScatterGather's semaphore, which it may share with its parent and children.
+func (sg *ScatterGather[T]) slotHolder(ctx context.Context) *runningTask {
+	if rt, ok := ctx.Value(runningKey{}).(*runningTask); ok && rt.semaphore == sg.semaphore && rt.running.Load() {
+		return rt
+	}
+	return nil
+}
+
+// Let the worker pool start another worker while a worker waits in Await, or
+// take that worker back once it stops waiting.
+func (sg *ScatterGather[T]) parkWorker(parked bool) {
+	if !sg.workerPool {
+		return
+	}
+	sg.queueLock.Lock()
+	if parked {
+		sg.workers--
+	} else {
+		sg.workers++
+	}
+	sg.queueLock.Unlock()
+	if parked {
+		sg.spawnWorkers()
+	}
+}
+
+// Wait for subtasks submitted from within a running task, and return their
+// results as AwaitAll does. Pass the context given to the running task: while
+// waiting, the task gives up its slot so the subtasks can use it, and it takes
+// a slot again before Await returns. This way tasks can recursively scatter
+// work over the same ScatterGather without deadlocking when all slots are
+// taken by tasks waiting for their subtasks.
+//
+// Tasks may submit subtasks even while Wait() is in progress, as long as they
+// do so before they return. When ctx does not belong to a running task of
+// this ScatterGather, or of one that shares its slots as created by
+// NewChild, Await is the same as AwaitAll.
+func (sg *ScatterGather[T]) Await(ctx context.Context, tasks ...*Task[T]) ([]T, error) {
+	rt := sg.slotHolder(ctx)
+	if rt == nil {
+		return AwaitAll(tasks...)
+	}
+	rt.semaphore.Release(rt.weight)
+	rt.park(true)
+	defer func() {
+		rt.park(false)
+		rt.semaphore.Acquire(context.Background(), rt.weight)
+	}()
+	return AwaitAll(tasks...)
+}
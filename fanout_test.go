@@ -0,0 +1,44 @@
+package scattergather
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAwaitSubtasks(t *testing.T) {
+	for _, pool := range []bool{false, true} {
+		sg := New[int](1)
+		if pool {
+			sg.UseWorkerPool(true)
+		}
+		var sum func(ctx context.Context, depth int) (int, error)
+		sum = func(ctx context.Context, depth int) (int, error) {
+			if depth == 0 {
+				return 1, nil
+			}
+			children := []*Task[int]{}
+			for i := 0; i < 2; i++ {
+				children = append(children, sg.RunContext(ctx, func(ctx context.Context) (int, error) {
+					return sum(ctx, depth-1)
+				}))
+			}
+			values, err := sg.Await(ctx, children...)
+			total := 0
+			for _, v := range values {
+				total += v
+			}
+			return total, err
+		}
+		root := sg.RunContext(context.Background(), func(ctx context.Context) (int, error) {
+			return sum(ctx, 3)
+		})
+		_, err := sg.Wait()
+		assert.Nil(t, err)
+		value, err := root.Result()
+		assert.Nil(t, err)
+		assert.Equal(t, 8, value, "Subtasks run while their parent waits for them")
+		assert.Equal(t, int64(0), sg.semaphore.InUse(), "All slots are released")
+	}
+}
@@ -0,0 +1,45 @@
+package scattergather
+
+// A Gatherer receives the outcome of tasks as they complete, instead of having
+// them collected in memory for Wait(). Its methods are called one at a time,
+// from the goroutine that gathers results. Finish is called once all tasks have
+// completed, and an error it returns is included in the error returned by
+// Wait().
+type Gatherer[T any] interface {
+	OnResult(T)
+	OnError(error)
+	Finish() error
+}
+
+// Pass the outcome of all tasks to gatherer instead of collecting them.
+// Wait() then returns no results, but still returns the aggregated error of
+// all tasks. This must be set before the first task is submitted.
+func (sg *ScatterGather[T]) SetGatherer(gatherer Gatherer[T]) {
+	sg.gatherer = gatherer
+}
+
+type channelGatherer[T any] struct {
+	ch chan<- T
+}
+
+func (g channelGatherer[T]) OnResult(value T) {
+	g.ch <- value
+}
+
+func (g channelGatherer[T]) OnError(error) {}
+
+func (g channelGatherer[T]) Finish() error {
+	close(g.ch)
+	return nil
+}
+
+// Send the value of every successful task to ch as soon as it completes,
+// instead of collecting them for Wait(). The channel is closed once Wait() has
+// been called and all tasks have completed, so it must be read from while
+// waiting. Wait() still returns the aggregated error of all tasks. This must be
+// set before the first task is submitted. As the channel is closed, Reset stops
+// sending results to it, so results are collected for Wait() again unless
+// GatherInto is called with a new channel.
+func (sg *ScatterGather[T]) GatherInto(ch chan<- T) {
+	sg.SetGatherer(channelGatherer[T]{ch: ch})
+}
@@ -0,0 +1,83 @@
+package scattergather
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type sumGatherer struct {
+	sum      int
+	failures int
+	finished bool
+}
+
+func (g *sumGatherer) OnResult(value int) {
+	g.sum += value
+}
+
+func (g *sumGatherer) OnError(err error) {
+	g.failures++
+}
+
+func (g *sumGatherer) Finish() error {
+	g.finished = true
+	return errors.New("finished")
+}
+
+func TestSetGatherer(t *testing.T) {
+	sg := New[int](0)
+	g := &sumGatherer{}
+	sg.SetGatherer(g)
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		sg.Run(ctx, squareOdds(i))
+	}
+	res, err := sg.Wait()
+	assert.Empty(t, res, "Results are not collected")
+	assert.Equal(t, 1+9+25+49+81, g.sum, "Results are passed to the gatherer")
+	assert.Equal(t, 5, g.failures, "Errors are passed to the gatherer")
+	assert.True(t, g.finished, "The gatherer is finished")
+	assert.Equal(t, 6, len(err.(*ScatteredError).Errors), "Errors are still aggregated")
+	assert.ErrorIs(t, err, &cantEven{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "finished", "The error returned by Finish is included")
+}
+
+func TestGatherInto(t *testing.T) {
+	sg := New[int](0)
+	ch := make(chan int)
+	sg.GatherInto(ch)
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		sg.Run(ctx, squareOdds(i))
+	}
+	errChan := make(chan error)
+	go func() {
+		_, err := sg.Wait()
+		errChan <- err
+	}()
+	sum := 0
+	for value := range ch {
+		sum += value
+	}
+	assert.Equal(t, 1+9+25+49+81, sum, "Results are sent to the channel")
+	assert.Equal(t, 5, len((<-errChan).(*ScatteredError).Errors), "Errors are still aggregated")
+
+	sg.Reset()
+	sg.Run(ctx, squareOdds(3))
+	results, err := sg.Wait()
+	assert.Nil(t, err)
+	assert.Equal(t, []int{9}, results, "After Reset, results are no longer sent to the closed channel")
+
+	ch = make(chan int, 1)
+	sg.Reset()
+	sg.GatherInto(ch)
+	sg.Run(ctx, squareOdds(5))
+	results, err = sg.Wait()
+	assert.Nil(t, err)
+	assert.Empty(t, results)
+	assert.Equal(t, 25, <-ch, "Results are sent to a new channel")
+}
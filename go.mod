@@ -1,10 +1,9 @@
 module github.com/seveas/scattergather
 
-go 1.18
+go 1.23
 
 require (
 	github.com/stretchr/testify v1.6.2-0.20201103103935-92707c0b2d50
-	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c
 )
 
 require (
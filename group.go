@@ -0,0 +1,35 @@
+package scattergather
+
+import (
+	"context"
+)
+
+// A ScatterGather for tasks that only return an error, such as writing files
+// or sending requests whose response does not matter.
+type Group struct {
+	*ScatterGather[struct{}]
+}
+
+// Create a new Group object that will run at most parallel tasks in parallel.
+// When parallel is 0, the maximum is set to GOMAXPROCS.
+func NewGroup(parallel int64) *Group {
+	g := &Group{ScatterGather: New[struct{}](parallel)}
+	g.DiscardResults(true)
+	return g
+}
+
+// Add a piece of work to be run. This will call the callable in a separate
+// goroutine. The error returned by this function will be collected and
+// returned from Wait()
+func (g *Group) Go(ctx context.Context, callable func() error) {
+	g.Run(ctx, func() (struct{}, error) {
+		return struct{}{}, callable()
+	})
+}
+
+// Wait for all tasks to complete, and return the aggregated error of all
+// tasks.
+func (g *Group) Wait() error {
+	_, err := g.ScatterGather.Wait()
+	return err
+}
@@ -0,0 +1,26 @@
+package scattergather
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup(t *testing.T) {
+	g := NewGroup(0)
+	ctx := context.Background()
+	var ran atomic.Int32
+	for i := 0; i < 10; i++ {
+		g.Go(ctx, func() error {
+			ran.Add(1)
+			_, err := squareOdds(i)()
+			return err
+		})
+	}
+	err := g.Wait()
+	assert.Equal(t, int32(10), ran.Load(), "All tasks are run")
+	assert.Equal(t, 5, len(err.(*ScatteredError).Errors), "Errors are returned")
+	assert.ErrorIs(t, err, &cantEven{})
+}
@@ -0,0 +1,108 @@
+package scattergather
+
+import (
+	"context"
+)
+
+// Call fn for all inputs, with at most parallel calls running in parallel,
+// and return the results in the order of the inputs. When parallel is 0, the
+// maximum is set to GOMAXPROCS. The returned slice has a value for every
+// input, the zero value for inputs for which fn failed or that were skipped,
+// and the errors are aggregated as Wait() does.
+func Map[A, B any](ctx context.Context, parallel int64, inputs []A, fn func(context.Context, A) (B, error)) ([]B, error) {
+	m := NewMapper(parallel, fn)
+	m.ExpectTasks(len(inputs))
+	for _, input := range inputs {
+		m.Submit(ctx, input)
+	}
+	results, err := m.WaitResults()
+	values := make([]B, len(inputs))
+	for _, res := range results {
+		values[res.Index] = res.Value
+	}
+	return values, err
+}
+
+// Call fn for all inputs, with at most parallel calls running in parallel, for
+// functions that only matter for their side effects. When parallel is 0, the
+// maximum is set to GOMAXPROCS. The errors are aggregated as Wait() does.
+func ForEach[A any](ctx context.Context, parallel int64, inputs []A, fn func(context.Context, A) error) error {
+	g := NewGroup(parallel)
+	g.ExpectTasks(len(inputs))
+	for _, input := range inputs {
+		g.RunContext(ctx, func(ctx context.Context) (struct{}, error) {
+			return struct{}{}, fn(ctx, input)
+		})
+	}
+	return g.Wait()
+}
+
+// Call pred for all inputs, with at most parallel calls running in parallel,
+// and return the inputs for which it returned true, in their original order.
+// When parallel is 0, the maximum is set to GOMAXPROCS. Inputs for which pred
+// failed are left out, and the errors are aggregated as Wait() does.
+func Filter[A any](ctx context.Context, parallel int64, inputs []A, pred func(context.Context, A) (bool, error)) ([]A, error) {
+	m := NewMapper(parallel, func(ctx context.Context, input A) (A, error) {
+		match, err := pred(ctx, input)
+		if err == nil && !match {
+			err = ErrSkip
+		}
+		return input, err
+	})
+	m.OrderedResults(true)
+	m.ExpectTasks(len(inputs))
+	for _, input := range inputs {
+		m.Submit(ctx, input)
+	}
+	return m.Wait()
+}
+
+// Call fn for all inputs, with at most parallel calls running in parallel, and
+// build a map from the keys and values it returns. When parallel is 0, the
+// maximum is set to GOMAXPROCS. When several inputs produce the same key, the
+// value of the last of them in the order of the inputs is kept. Inputs for
+// which fn failed are left out, and the errors are aggregated as Wait() does.
+func ToMap[A any, K comparable, V any](ctx context.Context, parallel int64, inputs []A, fn func(context.Context, A) (K, V, error)) (map[K]V, error) {
+	m := NewMapper(parallel, func(ctx context.Context, input A) (entry[K, V], error) {
+		key, value, err := fn(ctx, input)
+		return entry[K, V]{key, value}, err
+	})
+	m.OrderedResults(true)
+	m.ExpectTasks(len(inputs))
+	for _, input := range inputs {
+		m.Submit(ctx, input)
+	}
+	entries, err := m.Wait()
+	result := make(map[K]V, len(entries))
+	for _, e := range entries {
+		result[e.key] = e.value
+	}
+	return result, err
+}
+
+// A key and value produced by a task
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// Call fn for all entries of m, with at most parallel calls running in
+// parallel, and return a map from the same keys to the values fn returned.
+// When parallel is 0, the maximum is set to GOMAXPROCS. Keys for which fn
+// failed are left out, and the errors are aggregated as Wait() does.
+func MapValues[K comparable, V, R any](ctx context.Context, parallel int64, m map[K]V, fn func(context.Context, K, V) (R, error)) (map[K]R, error) {
+	sg := New[entry[K, R]](parallel)
+	sg.ExpectTasks(len(m))
+	for key, value := range m {
+		sg.RunContext(ctx, func(ctx context.Context) (entry[K, R], error) {
+			result, err := fn(ctx, key, value)
+			return entry[K, R]{key, result}, err
+		})
+	}
+	entries, err := sg.Wait()
+	result := make(map[K]R, len(entries))
+	for _, e := range entries {
+		result[e.key] = e.value
+	}
+	return result, err
+}
@@ -0,0 +1,73 @@
+package scattergather
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMap(t *testing.T) {
+	result, err := Map(context.Background(), 2, []string{"1", "2", "three", "4"}, func(ctx context.Context, s string) (int, error) {
+		return strconv.Atoi(s)
+	})
+	assert.Equal(t, []int{1, 2, 0, 4}, result, "Results are in the order of the inputs")
+	assert.Len(t, err.(*ScatteredError).Errors, 1, "Errors are aggregated")
+
+	result, err = Map(context.Background(), 2, []int{1, 2, 3, 4}, func(ctx context.Context, n int) (int, error) {
+		if n == 2 {
+			return n, ErrSkip
+		}
+		return n * n, nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 0, 9, 16}, result, "Skipped inputs keep their place")
+}
+
+func TestForEach(t *testing.T) {
+	var sum atomic.Int64
+	err := ForEach(context.Background(), 2, []int{1, 2, 3, 4}, func(ctx context.Context, n int) error {
+		sum.Add(int64(n))
+		if n%2 == 0 {
+			return errors.New("even")
+		}
+		return nil
+	})
+	assert.Equal(t, int64(10), sum.Load(), "The function is called for all inputs")
+	assert.Len(t, err.(*ScatteredError).Errors, 2, "Errors are aggregated")
+	assert.Nil(t, ForEach(context.Background(), 0, []int{}, func(context.Context, int) error { return nil }))
+}
+
+func TestFilter(t *testing.T) {
+	result, err := Filter(context.Background(), 2, []int{5, 2, 8, 3, 6, 7}, func(ctx context.Context, n int) (bool, error) {
+		if n == 7 {
+			return false, errors.New("seven")
+		}
+		return n > 4, nil
+	})
+	assert.Equal(t, []int{5, 8, 6}, result, "Matching inputs are returned in their original order")
+	assert.EqualError(t, err, "seven", "Errors are aggregated")
+}
+
+func TestToMap(t *testing.T) {
+	result, err := ToMap(context.Background(), 2, []string{"a", "bb", "cc", "dddd", ""}, func(ctx context.Context, s string) (int, string, error) {
+		if s == "" {
+			return 0, "", errors.New("empty")
+		}
+		return len(s), s, nil
+	})
+	assert.Equal(t, map[int]string{1: "a", 2: "cc", 4: "dddd"}, result, "The last value for a key is kept")
+	assert.EqualError(t, err, "empty", "Errors are aggregated")
+}
+
+func TestMapValues(t *testing.T) {
+	input := map[string]string{"one": "1", "two": "2", "three": "three"}
+	result, err := MapValues(context.Background(), 2, input, func(ctx context.Context, key, value string) (int, error) {
+		return strconv.Atoi(value)
+	})
+	assert.Equal(t, map[string]int{"one": 1, "two": 2}, result, "Results are kept under their keys")
+	assert.Len(t, err.(*ScatteredError).Errors, 1, "Errors are aggregated")
+}
@@ -0,0 +1,73 @@
+package scattergather
+
+import (
+	"context"
+	"sync"
+
+	"github.com/seveas/scattergather/semaphore"
+)
+
+// Limit the number of tasks submitted with RunKeyed that run in parallel for
+// the same key, in addition to the maximum parallelism of all tasks. This is
+// useful to limit the load on every host when fanning out to many hosts. This
+// must be set before the first task is submitted. A limit of 0 disables this
+// limit.
+func (sg *ScatterGather[T]) SetKeyLimit(limit int64) {
+	if limit == 0 {
+		sg.keys = nil
+		return
+	}
+	sg.keys = &keyLimiter{limit: limit, semaphores: make(map[string]*keySemaphore)}
+}
+
+// Add a piece of work to be run, like RunContext. At most as many tasks with
+// the same key as set with SetKeyLimit run in parallel.
+func (sg *ScatterGather[T]) RunKeyed(ctx context.Context, key string, callable func(context.Context) (T, error)) *Task[T] {
+	return sg.run(&task[T]{ctx: ctx, key: key, keyed: true, weight: 1, callable: callable})
+}
+
+type keyLimiter struct {
+	lock       sync.Mutex
+	limit      int64
+	semaphores map[string]*keySemaphore
+}
+
+// A semaphore for a single key, which is forgotten when no tasks use it
+type keySemaphore struct {
+	semaphore *semaphore.Weighted
+	users     int
+}
+
+// Wait for a slot for key, or until ctx is done
+func (kl *keyLimiter) acquire(ctx context.Context, key string) error {
+	kl.lock.Lock()
+	ks, ok := kl.semaphores[key]
+	if !ok {
+		ks = &keySemaphore{semaphore: semaphore.NewWeighted(kl.limit)}
+		kl.semaphores[key] = ks
+	}
+	ks.users++
+	kl.lock.Unlock()
+	err := ks.semaphore.Acquire(ctx, 1)
+	if err != nil {
+		kl.forget(key, ks)
+	}
+	return err
+}
+
+// Release a slot acquired for key
+func (kl *keyLimiter) release(key string) {
+	kl.lock.Lock()
+	ks := kl.semaphores[key]
+	kl.lock.Unlock()
+	ks.semaphore.Release(1)
+	kl.forget(key, ks)
+}
+
+func (kl *keyLimiter) forget(key string, ks *keySemaphore) {
+	kl.lock.Lock()
+	defer kl.lock.Unlock()
+	if ks.users--; ks.users == 0 {
+		delete(kl.semaphores, key)
+	}
+}
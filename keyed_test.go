@@ -0,0 +1,38 @@
+package scattergather
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunKeyed(t *testing.T) {
+	sg := New[int](100)
+	sg.SetKeyLimit(2)
+	ctx := context.Background()
+	lock := sync.Mutex{}
+	running := make(map[string]int)
+	peak := make(map[string]int)
+	for i := 0; i < 30; i++ {
+		key := []string{"a", "b", "c"}[i%3]
+		sg.RunKeyed(ctx, key, func(context.Context) (int, error) {
+			lock.Lock()
+			running[key]++
+			peak[key] = max(peak[key], running[key])
+			lock.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			lock.Lock()
+			running[key]--
+			lock.Unlock()
+			return i, nil
+		})
+	}
+	res, err := sg.Wait()
+	assert.Nil(t, err)
+	assert.Equal(t, 30, len(res), "All tasks are run")
+	assert.Equal(t, map[string]int{"a": 2, "b": 2, "c": 2}, peak, "At most two tasks run in parallel per key")
+	assert.Empty(t, sg.keys.semaphores, "Unused keys are forgotten")
+}
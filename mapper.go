@@ -0,0 +1,29 @@
+package scattergather
+
+import (
+	"context"
+)
+
+// A ScatterGather that runs the same worker function for every input
+// submitted to it. This avoids building a closure per task when mapping a
+// collection of inputs in parallel.
+type Mapper[In, Out any] struct {
+	*ScatterGather[Out]
+	worker func(context.Context, In) (Out, error)
+}
+
+// Create a new Mapper object that will call worker for every submitted input,
+// running at most parallel workers in parallel. When parallel is 0, the
+// maximum is set to GOMAXPROCS.
+func NewMapper[In, Out any](parallel int64, worker func(context.Context, In) (Out, error)) *Mapper[In, Out] {
+	return &Mapper[In, Out]{ScatterGather: New[Out](parallel), worker: worker}
+}
+
+// Submit an input to be processed. This will call the worker function in a
+// separate goroutine and pass the context and input. The result and error
+// returned by the worker will be collected and returned from Wait()
+func (m *Mapper[In, Out]) Submit(ctx context.Context, input In) *Task[Out] {
+	return m.RunContext(ctx, func(ctx context.Context) (Out, error) {
+		return m.worker(ctx, input)
+	})
+}
@@ -0,0 +1,24 @@
+package scattergather
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapper(t *testing.T) {
+	m := NewMapper(0, func(ctx context.Context, s string) (int, error) {
+		return strconv.Atoi(s)
+	})
+	ctx := context.Background()
+	for _, s := range []string{"1", "2", "3", "four", "5"} {
+		m.Submit(ctx, s)
+	}
+	result, err := m.Wait()
+	sort.Ints(result)
+	assert.Equal(t, []int{1, 2, 3, 5}, result, "All valid inputs are converted")
+	assert.Equal(t, 1, len(err.(*ScatteredError).Errors), "The invalid input returns an error")
+}
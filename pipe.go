@@ -0,0 +1,46 @@
+package scattergather
+
+import (
+	"context"
+)
+
+// Create a new ScatterGather object that runs fn for every successful result
+// of src as soon as it arrives, so the next stage of a pipeline overlaps with
+// the previous one instead of waiting for it to finish. The new object runs at
+// most GOMAXPROCS tasks in parallel, which can be changed with SetParallel.
+//
+// Pipe streams the results of src as Results() does, so src.Wait() returns
+// only the errors of src. Call src.Wait() once all tasks have been submitted
+// to src, then call Wait() on the returned object to get the results of the
+// second stage. Wait() on the returned object does not return before the
+// results of src have all been consumed.
+func Pipe[T, U any](src *ScatterGather[T], fn func(context.Context, T) (U, error)) *ScatterGather[U] {
+	dst := New[U](0)
+	pipe(context.Background(), src, dst, fn)
+	return dst
+}
+
+// Submit a task to dst for every successful result of src, until all results
+// of src have been consumed
+func pipe[T, U any](ctx context.Context, src *ScatterGather[T], dst *ScatterGather[U], fn func(context.Context, T) (U, error)) {
+	results := src.Results()
+	// The feeder counts as a running task of dst, so it can keep submitting
+	// tasks while dst.Wait() is in progress.
+	feeder := &runningTask{owner: dst}
+	feeder.running.Store(true)
+	ctx = context.WithValue(ctx, runningKey{}, feeder)
+	dst.stateLock.Lock()
+	dst.waitGroup.Add(1)
+	dst.stateLock.Unlock()
+	go func() {
+		defer dst.waitGroup.Done()
+		defer feeder.running.Store(false)
+		for res := range results {
+			if res.Err == nil {
+				dst.RunContext(ctx, func(ctx context.Context) (U, error) {
+					return fn(ctx, res.Value)
+				})
+			}
+		}
+	}()
+}
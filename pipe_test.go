@@ -0,0 +1,38 @@
+package scattergather
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipe(t *testing.T) {
+	src := New[int](0)
+	dst := Pipe(src, func(ctx context.Context, n int) (string, error) {
+		if n == 3 {
+			return "", errors.New("three")
+		}
+		return strconv.Itoa(n * 2), nil
+	})
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		src.Run(ctx, func() (int, error) {
+			if i == 4 {
+				return 0, errors.New("four")
+			}
+			return i, nil
+		})
+	}
+	_, err := src.Wait()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "four", "Errors of the first stage are returned by its Wait")
+	result, err := dst.Wait()
+	sort.Strings(result)
+	assert.Equal(t, []string{"0", "2", "4"}, result, "Successful results are passed to the next stage")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "three", "Errors of the second stage are returned by its Wait")
+}
@@ -0,0 +1,80 @@
+package scattergather
+
+import (
+	"context"
+)
+
+// A stage of a pipeline: a ScatterGather whose results are passed on to the
+// next stage as soon as they arrive. Each stage has its own limit on the
+// number of tasks running in parallel, and a stage that falls behind makes the
+// stages before it wait instead of piling up work.
+type Stage[T any] struct {
+	sg   *ScatterGather[T]
+	ctx  context.Context
+	prev []func() error
+}
+
+// Start a pipeline by running fn for all inputs, with at most parallel tasks
+// running in parallel. When parallel is 0, the maximum is set to GOMAXPROCS.
+func From[In, Out any](ctx context.Context, parallel int64, inputs []In, fn func(context.Context, In) (Out, error)) *Stage[Out] {
+	sg := newStage[Out](parallel)
+	submitted := make(chan struct{})
+	go func() {
+		defer close(submitted)
+		for _, input := range inputs {
+			sg.RunContext(ctx, func(ctx context.Context) (Out, error) {
+				return fn(ctx, input)
+			})
+		}
+	}()
+	wait := func() error {
+		<-submitted
+		_, err := sg.Wait()
+		return err
+	}
+	return &Stage[Out]{sg: sg, ctx: ctx, prev: []func() error{wait}}
+}
+
+// Add a stage to the pipeline that runs fn for every successful result of
+// stage s, with at most parallel tasks running in parallel. When parallel is
+// 0, the maximum is set to GOMAXPROCS.
+func Then[T, U any](s *Stage[T], parallel int64, fn func(context.Context, T) (U, error)) *Stage[U] {
+	sg := newStage[U](parallel)
+	pipe(s.ctx, s.sg, sg, fn)
+	wait := func() error {
+		_, err := sg.Wait()
+		return err
+	}
+	return &Stage[U]{sg: sg, ctx: s.ctx, prev: append(s.prev[:len(s.prev):len(s.prev)], wait)}
+}
+
+// Wait for all stages of the pipeline to finish, and return the results of
+// the last stage. Errors of all stages are combined into a single
+// *ScatteredError. Results that failed in one stage are not passed on to the
+// next.
+func (s *Stage[T]) Collect() ([]T, error) {
+	errs := &ScatteredError{}
+	for _, wait := range s.prev[:len(s.prev)-1] {
+		if err := wait(); err != nil {
+			errs.AddError(err)
+		}
+	}
+	results, err := s.sg.Wait()
+	if err != nil {
+		errs.AddError(err)
+	}
+	if errs.HasErrors() {
+		return results, errs.Flatten()
+	}
+	return results, nil
+}
+
+// Create the ScatterGather for a stage. No more tasks may wait to start than
+// the stage can run in parallel, so a stage that falls behind blocks the
+// previous stage from handing over more results.
+func newStage[T any](parallel int64) *ScatterGather[T] {
+	sg := New[T](parallel)
+	sg.UseWorkerPool(true)
+	sg.SetMaxPending(sg.parallel)
+	return sg
+}
@@ -0,0 +1,43 @@
+package scattergather
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipeline(t *testing.T) {
+	var running, peak atomic.Int64
+	parse := From(context.Background(), 4, []string{"1", "2", "x", "3", "4", "5"}, func(ctx context.Context, s string) (int, error) {
+		return strconv.Atoi(s)
+	})
+	double := Then(parse, 1, func(ctx context.Context, n int) (int, error) {
+		n2 := running.Add(1)
+		for p := peak.Load(); n2 > p && !peak.CompareAndSwap(p, n2); p = peak.Load() {
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		if n == 4 {
+			return 0, errors.New("four")
+		}
+		return n * 2, nil
+	})
+	format := Then(double, 2, func(ctx context.Context, n int) (string, error) {
+		return strconv.Itoa(n), nil
+	})
+	result, err := format.Collect()
+	sort.Strings(result)
+	assert.Equal(t, []string{"10", "2", "4", "6"}, result, "Results flow through all stages")
+	assert.Len(t, err.(*ScatteredError).Errors, 2, "Errors of all stages are aggregated")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid syntax")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "four")
+	assert.Equal(t, int64(1), peak.Load(), "Each stage has its own parallelism")
+}
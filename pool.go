@@ -0,0 +1,160 @@
+package scattergather
+
+// Instead of starting a goroutine for every submitted task, queue tasks and
+// let a pool of worker goroutines, no larger than the maximum parallelism,
+// execute them. This keeps the number of goroutines bounded when submitting
+// a large number of tasks. Like tasks that each wait for a slot in their own
+// goroutine, queued tasks get their slots in the order in which they were
+// submitted, unless they have different priorities. This must be set before
+// the first task is submitted.
+func (sg *ScatterGather[T]) UseWorkerPool(pool bool) {
+	sg.workerPool = pool
+}
+
+// Share slots between the priorities of tasks submitted with RunPriority in
+// proportion to their weights, instead of always starting tasks with a higher
+// priority first, so tasks with a lower priority are not starved. For example,
+// weights of 4 for priority 1 and 1 for priority 0 start four tasks with
+// priority 1 for every task with priority 0, as long as both are queued.
+// Priorities without a weight have a weight of 1. This must be set before the
+// first task is submitted. Nil weights restore strict priority ordering.
+func (sg *ScatterGather[T]) SetPriorityWeights(weights map[int]int) {
+	sg.queueLock.Lock()
+	sg.queue.weights = weights
+	sg.queueLock.Unlock()
+}
+
+func (sg *ScatterGather[T]) enqueue(t *task[T]) {
+	sg.queueLock.Lock()
+	sg.queue.push(t)
+	sg.queueLock.Unlock()
+	sg.spawnWorkers()
+}
+
+// Start as many workers as there are queued tasks, up to the maximum
+// parallelism
+func (sg *ScatterGather[T]) spawnWorkers() {
+	sg.queueLock.Lock()
+	defer sg.queueLock.Unlock()
+	for sg.workers < sg.parallel && sg.workers < int64(sg.queue.len()) {
+		sg.workers++
+		go sg.worker()
+	}
+}
+
+// Execute queued tasks until the queue is empty
+func (sg *ScatterGather[T]) worker() {
+	for t := sg.next(); t != nil; t = sg.next() {
+		sg.runTask(t)
+	}
+}
+
+// Take the next task from the queue and take its place in line for a slot, or
+// return nil and stop the worker if the queue is empty. Places in line are
+// taken while holding the queue lock, so tasks get their slots in the order in
+// which they were taken from the queue. A task that is heavier than the
+// maximum parallelism waits for it to be raised in a goroutine of its own,
+// instead of holding up a worker. So does a task that has to wait for its
+// start delay, its dependencies or the circuit breaker, after which it is
+// queued again.
+func (sg *ScatterGather[T]) next() *task[T] {
+	sg.queueLock.Lock()
+	defer sg.queueLock.Unlock()
+	for sg.queue.len() > 0 {
+		t := sg.queue.pop()
+		if sg.mustWait(t) {
+			go sg.await(t)
+			continue
+		}
+		sg.lineUp(t)
+		if t.weight <= sg.parallel {
+			return t
+		}
+		go sg.runTask(t)
+	}
+	sg.workers--
+	return nil
+}
+
+// Whether a queued task has to wait before it can start: for its start delay,
+// its dependencies or the circuit breaker to close
+func (sg *ScatterGather[T]) mustWait(t *task[T]) bool {
+	return (!t.waited && (t.delay > 0 || len(t.deps) > 0 || sg.startJitter > 0)) || (sg.breaker != nil && sg.breaker.open())
+}
+
+// Wait until a task can take its place in line for a slot in a goroutine of its
+// own, and queue it again. A task that fails while waiting is finished right
+// away.
+func (sg *ScatterGather[T]) await(t *task[T]) {
+	ctx, cancel := sg.taskContext(t.ctx)
+	waitCtx, stopWaiting := sg.waitContext(ctx)
+	t.waitErr = sg.waitToStart(waitCtx, t)
+	t.waited = true
+	stopWaiting()
+	cancel()
+	if t.waitErr != nil {
+		sg.runTask(t)
+	} else {
+		sg.enqueue(t)
+	}
+}
+
+// Queued tasks, grouped by priority. Without weights, tasks with a higher
+// priority are always started first. With weights, priorities take turns in
+// proportion to their weight, using stride scheduling.
+type taskQueue[T any] struct {
+	classes map[int]*priorityClass[T]
+	weights map[int]int
+	size    int
+	pass    float64
+}
+
+// The queued tasks of a single priority, in submission order
+type priorityClass[T any] struct {
+	tasks []*task[T]
+	pass  float64
+}
+
+func (q *taskQueue[T]) len() int {
+	return q.size
+}
+
+func (q *taskQueue[T]) push(t *task[T]) {
+	if q.classes == nil {
+		q.classes = make(map[int]*priorityClass[T])
+	}
+	class, ok := q.classes[t.priority]
+	if !ok {
+		class = &priorityClass[T]{}
+		q.classes[t.priority] = class
+	}
+	if len(class.tasks) == 0 {
+		// Don't let a priority that was idle catch up on the turns it missed
+		class.pass = max(class.pass, q.pass)
+	}
+	class.tasks = append(class.tasks, t)
+	q.size++
+}
+
+func (q *taskQueue[T]) pop() *task[T] {
+	var next *priorityClass[T]
+	nextPriority := 0
+	for priority, class := range q.classes {
+		if len(class.tasks) == 0 {
+			continue
+		}
+		if next == nil || (q.weights != nil && class.pass < next.pass) ||
+			((q.weights == nil || class.pass == next.pass) && priority > nextPriority) {
+			next, nextPriority = class, priority
+		}
+	}
+	t := next.tasks[0]
+	next.tasks[0] = nil
+	next.tasks = next.tasks[1:]
+	q.size--
+	if q.weights != nil {
+		q.pass = next.pass
+		next.pass += 1 / float64(max(q.weights[nextPriority], 1))
+	}
+	return t
+}
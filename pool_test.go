@@ -0,0 +1,150 @@
+package scattergather
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPool(t *testing.T) {
+	sg := New[int](5)
+	sg.UseWorkerPool(true)
+	ctx := context.Background()
+	before := runtime.NumGoroutine()
+	expected := make([]int, 1000)
+	for i := range expected {
+		expected[i] = i * i
+		sg.RunContext(ctx, func(ctx context.Context) (int, error) {
+			time.Sleep(time.Millisecond)
+			return i * i, nil
+		})
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine()-before, 6, "At most 5 workers and a gatherer are started")
+	results, err := sg.Wait()
+	assert.Nil(t, err)
+	sort.Ints(results)
+	assert.Equal(t, expected, results, "All tasks are executed")
+}
+
+func TestRunPriority(t *testing.T) {
+	sg := New[int](1)
+	sg.OrderedResults(true)
+	ctx := context.Background()
+	release := make(chan struct{})
+	var order []int
+	sg.RunPriority(ctx, 10, func() (int, error) {
+		<-release
+		return 0, nil
+	})
+	for i, priority := range []int{1, 3, 2, 3} {
+		sg.RunPriority(ctx, priority, func() (int, error) {
+			order = append(order, i+1)
+			return i + 1, nil
+		})
+	}
+	close(release)
+	results, err := sg.Wait()
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, results, "Results are returned in submission order")
+	assert.Equal(t, []int{2, 4, 3, 1}, order, "Tasks are started in order of priority")
+}
+
+func TestSetPriorityWeights(t *testing.T) {
+	sg := New[int](1)
+	sg.SetPriorityWeights(map[int]int{1: 4, 0: 1})
+	ctx := context.Background()
+	release := make(chan struct{})
+	var order []int
+	sg.RunPriority(ctx, 10, func() (int, error) {
+		<-release
+		return 0, nil
+	})
+	for i := 0; i < 16; i++ {
+		priority := i % 2
+		sg.RunPriority(ctx, priority, func() (int, error) {
+			order = append(order, priority)
+			return priority, nil
+		})
+	}
+	close(release)
+	_, err := sg.Wait()
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 0, 1, 1, 1, 1, 0, 1, 1, 1, 0, 0, 0, 0, 0, 0}, order, "Priorities take turns according to their weights")
+}
+
+func TestWorkerPoolOrder(t *testing.T) {
+	for _, pool := range []bool{true, false} {
+		testStartOrder(t, pool)
+	}
+}
+
+func testStartOrder(t *testing.T, pool bool) {
+	sg := New[int](1)
+	sg.UseWorkerPool(pool)
+	ctx := context.Background()
+	release := make(chan struct{})
+	order := make([]int, 0, 100)
+	for i := 0; i < 100; i++ {
+		sg.Run(ctx, func() (int, error) {
+			if i == 0 {
+				<-release
+			}
+			order = append(order, i)
+			return i, nil
+		})
+	}
+	close(release)
+	_, err := sg.Wait()
+	assert.Nil(t, err)
+	for i, index := range order {
+		if !assert.Equal(t, i, index, "Tasks start in submission order") {
+			break
+		}
+	}
+}
+
+func TestWorkerPoolOversized(t *testing.T) {
+	sg := New[int](2)
+	sg.UseWorkerPool(true)
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	sg.RunWeighted(ctx, 5, func() (int, error) { return 5, nil })
+	done := make(chan struct{})
+	sg.Run(ctx, func() (int, error) {
+		close(done)
+		return 1, nil
+	})
+	select {
+	case <-done:
+	case <-time.After(100 * time.Millisecond):
+		t.Error("A task that does not fit holds up the worker pool")
+	}
+	sg.SetParallel(5)
+	results, err := sg.Wait()
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []int{1, 5}, results, "A task that does not fit runs once it does")
+}
+
+func TestWorkerPoolDelayed(t *testing.T) {
+	sg := New[int](1)
+	sg.UseWorkerPool(true)
+	ctx := context.Background()
+	sg.RunAfter(ctx, 300*time.Millisecond, func() (int, error) { return 2, nil })
+	done := make(chan struct{})
+	sg.Run(ctx, func() (int, error) {
+		close(done)
+		return 1, nil
+	})
+	select {
+	case <-done:
+	case <-time.After(200 * time.Millisecond):
+		t.Error("A delayed task holds up the worker pool")
+	}
+	results, err := sg.Wait()
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []int{1, 2}, results, "A delayed task runs once its delay has passed")
+}
@@ -0,0 +1,79 @@
+package scattergather
+
+import (
+	"time"
+)
+
+// Counts of tasks in each stage of their life, as returned by
+// ScatterGather.Progress(). Tasks that were skipped, or whose errors were
+// ignored, count as neither succeeded nor failed.
+type Progress struct {
+	Submitted int
+	Pending   int
+	Running   int
+	Succeeded int
+	Failed    int
+}
+
+// Returns how many tasks have been submitted, are waiting to start, are
+// running, and have succeeded or failed. This can be used to display the
+// progress of a long running batch.
+func (sg *ScatterGather[T]) Progress() Progress {
+	// Load the counters for later stages first, so a task that moves on while
+	// we're loading them is never counted twice
+	ended := sg.ended.Load()
+	succeeded := sg.successes.Load()
+	failed := sg.failures.Load()
+	running := sg.running.Load()
+	submitted := sg.submitted.Load()
+	return Progress{
+		Submitted: int(submitted),
+		Pending:   int(max(submitted-running-ended, 0)),
+		Running:   int(running),
+		Succeeded: int(succeeded),
+		Failed:    int(failed),
+	}
+}
+
+// The kind of event passed to an OnEvent callback
+type EventType int
+
+const (
+	// A task has acquired a slot and started running
+	TaskStarted EventType = iota
+	// A task has finished, whether it ran or not
+	TaskFinished
+)
+
+// An event in the life of a task. For TaskFinished events, Duration is the
+// time the task was running, and Err is the error it returned, if any.
+type Event struct {
+	Type     EventType
+	Index    int
+	Label    string
+	Duration time.Duration
+	Err      error
+}
+
+// Call callback whenever a task starts or finishes. The callback is called from
+// the goroutines running the tasks, so it must be safe for concurrent use, and
+// should return quickly. This must be set before the first task is submitted.
+func (sg *ScatterGather[T]) OnEvent(callback func(Event)) {
+	sg.onEvent = callback
+}
+
+// The occupancy of the slots that limit the number of tasks running in
+// parallel, as returned by ScatterGather.Slots(). Waiting is the number of
+// tasks that are waiting for a slot.
+type Slots struct {
+	Size    int64
+	InUse   int64
+	Waiting int
+}
+
+// Returns how many slots there are, how many of them are in use, and how many
+// tasks are waiting for one, for monitoring purposes.
+func (sg *ScatterGather[T]) Slots() Slots {
+	sg.init(0)
+	return Slots{Size: sg.semaphore.Size(), InUse: sg.semaphore.InUse(), Waiting: sg.semaphore.Waiters()}
+}
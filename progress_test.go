@@ -0,0 +1,67 @@
+package scattergather
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress(t *testing.T) {
+	sg := New[int](2)
+	ctx := context.Background()
+	assert.Equal(t, Progress{}, sg.Progress(), "Nothing has happened yet")
+	for i := 0; i < 5; i++ {
+		sg.Run(ctx, func() (int, error) {
+			time.Sleep(300 * time.Millisecond)
+			return squareOdds(i)()
+		})
+	}
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, Progress{Submitted: 5, Pending: 3, Running: 2}, sg.Progress(), "Two tasks are running")
+	sg.Wait()
+	assert.Equal(t, Progress{Submitted: 5, Succeeded: 2, Failed: 3}, sg.Progress(), "All tasks are done")
+}
+
+func TestOnEvent(t *testing.T) {
+	sg := New[int](0)
+	lock := sync.Mutex{}
+	events := make(map[string][]Event)
+	sg.OnEvent(func(e Event) {
+		lock.Lock()
+		defer lock.Unlock()
+		events[e.Label] = append(events[e.Label], e)
+	})
+	ctx := context.Background()
+	sg.RunNamed(ctx, "sleeper", func(context.Context) (int, error) { return sleepTest(1)() })
+	sg.RunNamed(ctx, "failure", func(context.Context) (int, error) { return squareOdds(2)() })
+	sg.Wait()
+	assert.Equal(t, 2, len(events["sleeper"]), "Start and finish events are emitted")
+	assert.Equal(t, TaskStarted, events["sleeper"][0].Type)
+	assert.Equal(t, TaskFinished, events["sleeper"][1].Type)
+	assert.GreaterOrEqual(t, events["sleeper"][1].Duration, 500*time.Millisecond, "The duration is reported")
+	assert.Equal(t, 2, len(events["failure"]), "Start and finish events are emitted")
+	assert.ErrorIs(t, events["failure"][1].Err, &cantEven{}, "Errors are reported")
+}
+
+func TestSlots(t *testing.T) {
+	sg := New[int](2)
+	assert.Equal(t, Slots{Size: 2}, sg.Slots(), "No slots are in use initially")
+	ctx := context.Background()
+	release := make(chan struct{})
+	for i := 0; i < 5; i++ {
+		sg.Run(ctx, func() (int, error) {
+			<-release
+			return i, nil
+		})
+	}
+	for sg.Slots().Waiting < 3 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, Slots{Size: 2, InUse: 2, Waiting: 3}, sg.Slots(), "Slot usage is reported")
+	close(release)
+	sg.Wait()
+	assert.Equal(t, Slots{Size: 2}, sg.Slots(), "All slots are released")
+}
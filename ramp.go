@@ -0,0 +1,41 @@
+package scattergather
+
+import "time"
+
+// Start every batch of tasks with a maximum parallelism of one, and raise it
+// gradually to the configured maximum over the given duration, to avoid
+// overwhelming cold backends or caches. The ramp-up starts when the first task
+// of a batch is submitted. A duration of 0 disables ramping up.
+func (sg *ScatterGather[T]) SetRampUp(duration time.Duration) {
+	sg.rampUp = duration
+}
+
+// Start ramping up the maximum parallelism from one to target. The ramp-up is
+// stopped and the parallelism restored to target when the batch completes.
+func (sg *ScatterGather[T]) startRamp(target int64) {
+	sg.SetParallel(1)
+	stop, done := make(chan struct{}), make(chan struct{})
+	go sg.ramp(stop, done, target)
+	sg.stopRamp = func() {
+		close(stop)
+		<-done
+		sg.SetParallel(target)
+	}
+}
+
+// Ramp up the maximum parallelism to target, until stop is closed
+func (sg *ScatterGather[T]) ramp(stop, done chan struct{}, target int64) {
+	defer close(done)
+	steps := min(target, 100)
+	ticker := time.NewTicker(max(sg.rampUp/time.Duration(steps), 1))
+	defer ticker.Stop()
+	for step := int64(1); step < steps; step++ {
+		select {
+		case <-ticker.C:
+			// Computed in parts, as target*(step+1) overflows for Unlimited
+			sg.SetParallel(max(target/steps*(step+1)+target%steps*(step+1)/steps, 1))
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package scattergather
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRampUp(t *testing.T) {
+	sg := New[int](4)
+	sg.SetRampUp(400 * time.Millisecond)
+	parallel := func() int64 {
+		sg.queueLock.Lock()
+		defer sg.queueLock.Unlock()
+		return sg.parallel
+	}
+	ctx := context.Background()
+	sg.RunContext(ctx, func(ctx context.Context) (int, error) {
+		time.Sleep(300 * time.Millisecond)
+		return 0, nil
+	})
+	assert.Equal(t, int64(1), parallel(), "Parallelism starts at one")
+	time.Sleep(200 * time.Millisecond)
+	assert.Greater(t, parallel(), int64(1), "Parallelism is raised over time")
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, int64(4), parallel(), "Parallelism reaches the maximum")
+	sg.Wait()
+
+	sg = New[int](4)
+	sg.SetRampUp(time.Hour)
+	sg.Run(ctx, square(1))
+	sg.Wait()
+	assert.Equal(t, int64(4), parallel(), "Parallelism is restored when the batch is done")
+
+	sg = New[int](Unlimited)
+	sg.SetRampUp(20 * time.Millisecond)
+	sg.RunContext(ctx, func(ctx context.Context) (int, error) {
+		time.Sleep(100 * time.Millisecond)
+		return 0, nil
+	})
+	sg.Wait()
+	assert.Equal(t, Unlimited, parallel(), "Parallelism ramps up to Unlimited")
+
+	sg = New[int](4)
+	sg.SetRampUp(time.Nanosecond)
+	sg.Run(ctx, square(1))
+	sg.Wait()
+	assert.Equal(t, int64(4), parallel(), "A ramp-up shorter than its steps does not panic")
+}
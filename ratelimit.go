@@ -0,0 +1,63 @@
+package scattergather
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Anything that can throttle the rate at which tasks start, such as a
+// *rate.Limiter from golang.org/x/time/rate
+type RateLimiter interface {
+	Wait(ctx context.Context) error
+}
+
+// Throttle the rate at which tasks start using limiter, in addition to the
+// limit on the number of tasks that run in parallel. Tasks whose context is
+// done while waiting for the limiter fail with the limiter's error. A nil
+// limiter disables rate limiting. This must be set before the first task is
+// submitted.
+func (sg *ScatterGather[T]) SetRateLimit(limiter RateLimiter) {
+	sg.limiter = limiter
+}
+
+// Create a simple RateLimiter that allows perSecond events per second, with
+// bursts of at most burst events. This panics if perSecond is not positive.
+func NewRateLimiter(perSecond float64, burst int) RateLimiter {
+	// Written this way to also reject NaN
+	if !(perSecond > 0) {
+		panic("scattergather: rate limit must be positive")
+	}
+	return &tokenBucket{interval: time.Duration(float64(time.Second) / perSecond), burst: max(burst, 1)}
+}
+
+type tokenBucket struct {
+	lock     sync.Mutex
+	interval time.Duration
+	burst    int
+	next     time.Time
+}
+
+// Reserve the next token, and wait until it is available
+func (tb *tokenBucket) Wait(ctx context.Context) error {
+	tb.lock.Lock()
+	earliest := time.Now().Add(-time.Duration(tb.burst-1) * tb.interval)
+	if tb.next.Before(earliest) {
+		tb.next = earliest
+	}
+	at := tb.next
+	tb.next = tb.next.Add(tb.interval)
+	tb.lock.Unlock()
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,37 @@
+package scattergather
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	sg := New[time.Time](10)
+	sg.SetRateLimit(NewRateLimiter(20, 2))
+	sg.OrderedResults(true)
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		sg.Run(ctx, func() (time.Time, error) { return time.Now(), nil })
+	}
+	results, err := sg.Wait()
+	assert.Nil(t, err)
+	assert.Equal(t, 6, len(results))
+	assert.GreaterOrEqual(t, time.Since(start), 190*time.Millisecond, "Task starts are throttled")
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "Bursts are allowed")
+
+	sg = New[time.Time](10)
+	sg.SetRateLimit(NewRateLimiter(1, 1))
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	sg.Run(ctx, func() (time.Time, error) { return time.Now(), nil })
+	sg.Run(ctx, func() (time.Time, error) { return time.Now(), nil })
+	_, err = sg.Wait()
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "Tasks fail when their context is done while throttled")
+
+	assert.PanicsWithValue(t, "scattergather: rate limit must be positive", func() { NewRateLimiter(0, 1) })
+	assert.PanicsWithValue(t, "scattergather: rate limit must be positive", func() { NewRateLimiter(-1, 1) })
+}
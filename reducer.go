@@ -0,0 +1,46 @@
+package scattergather
+
+// A ScatterGather that folds the value of every successful task into an
+// accumulator as it arrives, instead of collecting all values. This keeps
+// memory usage flat no matter how many tasks are run.
+type Reducer[T, U any] struct {
+	*ScatterGather[T]
+	initial U
+	value   U
+	reduce  func(U, T) U
+}
+
+// Create a new Reducer object that will run at most parallel tasks in
+// parallel, and fold their values into initial using reduce. When parallel is
+// 0, the maximum is set to GOMAXPROCS.
+func NewReducer[T, U any](parallel int64, initial U, reduce func(U, T) U) *Reducer[T, U] {
+	r := &Reducer[T, U]{ScatterGather: New[T](parallel), initial: initial, value: initial, reduce: reduce}
+	r.SetGatherer(reduceGatherer[T, U]{r})
+	return r
+}
+
+// Wait for all tasks to complete, and return the reduced value, and the
+// aggregated error of all tasks.
+func (r *Reducer[T, U]) Wait() (U, error) {
+	_, err := r.ScatterGather.Wait()
+	return r.value, err
+}
+
+type reduceGatherer[T, U any] struct {
+	r *Reducer[T, U]
+}
+
+func (g reduceGatherer[T, U]) OnResult(value T) {
+	g.r.value = g.r.reduce(g.r.value, value)
+}
+
+func (g reduceGatherer[T, U]) OnError(error) {}
+
+// Every batch is folded into the initial value again
+func (g reduceGatherer[T, U]) reset() {
+	g.r.value = g.r.initial
+}
+
+func (g reduceGatherer[T, U]) Finish() error {
+	return nil
+}
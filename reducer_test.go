@@ -0,0 +1,38 @@
+package scattergather
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReducer(t *testing.T) {
+	r := NewReducer(0, int64(0), func(sum int64, value int) int64 {
+		return sum + int64(value)
+	})
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		r.Run(ctx, squareOdds(i))
+	}
+	sum, err := r.Wait()
+	assert.Equal(t, int64(1+9+25+49+81), sum, "All values are reduced")
+	assert.Equal(t, 5, len(err.(*ScatteredError).Errors), "Errors are still aggregated")
+}
+
+func TestReducerReset(t *testing.T) {
+	r := NewReducer(0, int64(100), func(sum int64, value int) int64 {
+		return sum + int64(value)
+	})
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		r.Run(ctx, squareOdds(i))
+	}
+	sum, _ := r.Wait()
+	assert.Equal(t, int64(100+1+9+25+49+81), sum)
+	r.Reset()
+	r.Run(ctx, squareOdds(3))
+	sum, err := r.Wait()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100+9), sum, "A new batch starts from the initial value")
+}
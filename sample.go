@@ -0,0 +1,41 @@
+package scattergather
+
+import (
+	"math/rand"
+)
+
+// A Gatherer that keeps a uniform random sample of at most n values instead
+// of all of them, using reservoir sampling. Count is the number of values the
+// sample was taken from.
+type Sample[T any] struct {
+	Count  int
+	n      int
+	values []T
+}
+
+// Create a new Sample gatherer that keeps at most n values. Use it with
+// ScatterGather.SetGatherer, and get the values with Results once Wait() has
+// returned.
+func NewSample[T any](n int) *Sample[T] {
+	return &Sample[T]{n: n, values: make([]T, 0, n)}
+}
+
+func (s *Sample[T]) OnResult(value T) {
+	s.Count++
+	if len(s.values) < s.n {
+		s.values = append(s.values, value)
+	} else if i := rand.Intn(s.Count); i < s.n {
+		s.values[i] = value
+	}
+}
+
+func (s *Sample[T]) OnError(error) {}
+
+func (s *Sample[T]) Finish() error {
+	return nil
+}
+
+// Returns the values in the sample
+func (s *Sample[T]) Results() []T {
+	return s.values
+}
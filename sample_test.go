@@ -0,0 +1,34 @@
+package scattergather
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSample(t *testing.T) {
+	sg := New[int](0)
+	sample := NewSample[int](10)
+	sg.SetGatherer(sample)
+	ctx := context.Background()
+	for i := 0; i < 1000; i++ {
+		sg.Run(ctx, squareOdds(i))
+	}
+	_, err := sg.Wait()
+	assert.Equal(t, 500, len(err.(*ScatteredError).Errors), "Errors are returned")
+	assert.Equal(t, 500, sample.Count, "All values are counted")
+	assert.Equal(t, 10, len(sample.Results()), "A sample of values is kept")
+	for _, value := range sample.Results() {
+		assert.Equal(t, 1, value%2, "Only successful values are sampled")
+	}
+
+	sg = New[int](0)
+	sample = NewSample[int](10)
+	sg.SetGatherer(sample)
+	for i := 0; i < 5; i++ {
+		sg.Run(ctx, square(i))
+	}
+	sg.Wait()
+	assert.ElementsMatch(t, []int{0, 1, 4, 9, 16}, sample.Results(), "Small batches are kept completely")
+}
@@ -4,29 +4,140 @@ package scattergather
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"math/rand"
 	"runtime"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/seveas/scattergather/x/sync/semaphore"
+	"github.com/seveas/scattergather/semaphore"
 )
 
 type ScatterGather[T any] struct {
 	waitGroup      *sync.WaitGroup
-	results        []T
+	results        []Result[T]
+	resultsLock    sync.Mutex
+	completed      int
+	succeeded      int
+	quorum         int
+	quorumChan     chan struct{}
 	keepAllResults bool
+	discardResults bool
+	orderedResults bool
+	failFast       bool
+	joinErrors     bool
+	flattenErrors  bool
+	recoverPanics  bool
+	taskTimeout    time.Duration
+	retries        int
+	retryBackoff   time.Duration
+	classifier     func(error) ErrorClass
+	hedgeDelay     time.Duration
 	errors         *ScatteredError
-	resultChan     chan scatterResult[T]
+	resultChan     chan Result[T]
+	streamChan     chan Result[T]
+	streamLock     sync.Mutex
+	unstreamed     []Result[T]
 	doneChan       chan interface{}
 	initOnce       sync.Once
 	gatherOnce     sync.Once
+	completeOnce   *sync.Once
+	stateLock      sync.Mutex
+	closed         bool
+	finished       bool
 	semaphore      *semaphore.Weighted
+	ctx            context.Context
+	cancel         context.CancelFunc
+	pendingCtx     context.Context
+	cancelPending  context.CancelFunc
+	abortChan      chan struct{}
+	abortReason    error
+	parentCtx      context.Context
+	submitted      atomic.Int64
+	parallel       int64
+	workerPool     bool
+	workers        int64
+	queue          taskQueue[T]
+	queueLock      sync.Mutex
+	pending        *semaphore.Weighted
+	maxFailures    int64
+	failures       atomic.Int64
+	strikes        atomic.Int64
+	halted         atomic.Bool
+	skipped        atomic.Int64
+	successes      atomic.Int64
+	running        atomic.Int64
+	ended          atomic.Int64
+	breaker        *circuitBreaker
+	adaptive       *aimd
+	rampUp         time.Duration
+	stopRamp       func()
+	limiter        RateLimiter
+	startJitter    time.Duration
+	minDuration    time.Duration
+	queueTimeout   time.Duration
+	keys           *keyLimiter
+	cache          Cache[T]
+	onEvent        func(Event)
+	trackProcs     bool
+	procs          atomic.Int64
+	onResult       func(T)
+	onError        func(error)
+	gatherer       Gatherer[T]
+	resultKey      func(T) any
+	less           func(a, b T) bool
+	limit          int
+	expected       int
+	merge          func(acc, next T) T
+	merged         int
+	seen           map[any]struct{}
 }
 
-type scatterResult[T any] struct {
-	val T
-	err error
+// A submitted piece of work, waiting to be executed
+type task[T any] struct {
+	ctx       context.Context
+	label     string
+	key       string
+	keyed     bool
+	priority  int
+	queued    bool
+	delay     time.Duration
+	handle    *Task[T]
+	deps      []*Task[T]
+	index     int
+	weight    int64
+	callable  func(context.Context) (T, error)
+	pending   bool
+	acquired  bool
+	waited    bool
+	waitErr   error
+	reserved  *semaphore.Reservation
+	started   bool
+	startTime time.Time
 }
 
+// The outcome of a single task: the value and error it returned, the order in
+// which it was submitted, starting at 0, and its label if it has one.
+type Result[T any] struct {
+	Value T
+	Err   error
+	Index int
+	Label string
+	skip  bool
+}
+
+// Pass this as the maximum parallelism to New or SetParallel to run all tasks
+// in parallel, for example when tasks spend all their time waiting for I/O.
+const Unlimited int64 = math.MaxInt64
+
 // Create a new ScatterGather object that will run at most parallel tasks in
 // parallel. When parallel is 0, the maximum is set to GOMAXPROCS.
 func New[T any](parallel int64) *ScatterGather[T] {
@@ -35,83 +146,1330 @@ func New[T any](parallel int64) *ScatterGather[T] {
 	return sg
 }
 
+// Create a new ScatterGather object that will run at most GOMAXPROCS times
+// factor tasks in parallel, but at least one. I/O bound tasks usually benefit
+// from running several times as many tasks as there are CPUs.
+func NewWithFactor[T any](factor float64) *ScatterGather[T] {
+	return New[T](max(int64(float64(runtime.GOMAXPROCS(0))*factor), 1))
+}
+
+// Create a new ScatterGather object like New, in fail-fast mode. The returned
+// context is derived from ctx and is cancelled when the first task fails or
+// when Wait returns, whichever happens first. Tasks receive a context derived
+// from both this context and the one passed to Run.
+func WithContext[T any](ctx context.Context, parallel int64) (*ScatterGather[T], context.Context) {
+	sg := New[T](parallel)
+	sg.FailFast(true)
+	sg.parentCtx = ctx
+	sg.ctx, sg.cancel = context.WithCancel(ctx)
+	return sg, sg.ctx
+}
+
+// Change the maximum number of tasks to run in parallel, also while tasks are
+// running. When parallel is 0, the maximum is set to GOMAXPROCS. When the
+// maximum is lowered below the number of running tasks, those tasks are not
+// interrupted, but no new tasks are started until enough of them have
+// finished to get below the new maximum.
 func (sg *ScatterGather[T]) SetParallel(parallel int64) {
-	sg.semaphore.SetSize(parallel)
+	if parallel == 0 {
+		parallel = int64(runtime.GOMAXPROCS(0))
+	}
+	sg.queueLock.Lock()
+	sg.parallel = parallel
+	sg.queueLock.Unlock()
+	sg.semaphore.Resize(parallel)
+	sg.spawnWorkers()
+}
+
+// Keep the maximum parallelism equal to GOMAXPROCS, also when it changes
+// while tasks are being submitted, for example because the CPU quota of the
+// container changed. GOMAXPROCS is checked whenever a task is submitted. This
+// overrides the maximum set with New or SetParallel.
+func (sg *ScatterGather[T]) TrackGOMAXPROCS(track bool) {
+	sg.procs.Store(0)
+	sg.trackProcs = track
 }
 
 func (sg *ScatterGather[T]) KeepAllResults(keep bool) {
 	sg.keepAllResults = keep
 }
 
+// Stop once n subtasks have returned successfully, and cancel the remaining
+// ones. The outcome of tasks that complete after that is discarded, and Wait()
+// returns the n values without an error. When fewer subtasks succeed, Wait()
+// returns as usual. A limit of 0 disables this limit.
+func (sg *ScatterGather[T]) Limit(n int) {
+	sg.limit = n
+}
+
+// Allocate room for n results and errors up front, to avoid growing the
+// slices they are gathered in when running many tasks. This must be called
+// before the first task is submitted, and is kept when the object is Reset.
+func (sg *ScatterGather[T]) ExpectTasks(n int) {
+	sg.init(0)
+	sg.expected = n
+	sg.results = make([]Result[T], 0, n)
+	sg.errors.Errors = make([]error, 0, n)
+}
+
+// Do not collect the values of tasks, for tasks that only matter for their
+// error. Wait() then returns no results, only the aggregated error.
+func (sg *ScatterGather[T]) DiscardResults(discard bool) {
+	sg.discardResults = discard
+}
+
+// Merge the values of all successful tasks into a single value using merge,
+// instead of collecting them, so Wait() returns at most one value. The value
+// of the first task to succeed is passed as acc to the first call, and merge
+// may modify and return it. This is useful when tasks return partial maps or
+// sets.
+func (sg *ScatterGather[T]) MergeResults(merge func(acc, next T) T) {
+	sg.merge = merge
+}
+
+// Whether to return results in the order in which tasks were submitted, rather
+// than in the order in which they completed.
+func (sg *ScatterGather[T]) OrderedResults(ordered bool) {
+	sg.orderedResults = ordered
+}
+
+// Keep results sorted by their values, using less to compare them, so Wait()
+// returns them in sorted order. Results that compare equal are kept in the
+// order in which they completed. This takes precedence over OrderedResults,
+// and must be set before the first task is submitted.
+func (sg *ScatterGather[T]) SortResults(less func(a, b T) bool) {
+	sg.less = less
+}
+
+// Return errors combined with errors.Join instead of as a *ScatteredError,
+// for callers that prefer standard library error trees.
+func (sg *ScatterGather[T]) JoinErrors(join bool) {
+	sg.joinErrors = join
+}
+
+// Merge errors from nested scatter/gather operations into the returned error,
+// instead of returning a tree of errors. See ScatteredError.Flatten.
+func (sg *ScatterGather[T]) FlattenErrors(flatten bool) {
+	sg.flattenErrors = flatten
+}
+
+// Collapse identical error messages in the string representation of the
+// returned *ScatteredError. All errors are still available in its Errors.
+func (sg *ScatterGather[T]) DeduplicateErrors(dedup bool) {
+	sg.init(0)
+	sg.errors.Deduplicate = dedup
+}
+
+// Drop successful results whose key, as returned by key, is the same as that
+// of a result that was gathered earlier. The keys must be comparable. Dropped
+// results are not returned, streamed or passed to callbacks, and do not count
+// towards WaitN. This must be set before the first task is submitted.
+func (sg *ScatterGather[T]) DeduplicateResults(key func(T) any) {
+	sg.resultKey = key
+}
+
+// Drop successful results that are equal to a result that was gathered
+// earlier, using the values themselves as keys for DeduplicateResults.
+func Distinct[T comparable](sg *ScatterGather[T]) {
+	sg.DeduplicateResults(func(value T) any { return value })
+}
+
+// Store at most max errors in the returned *ScatteredError, and only count
+// any further errors. This bounds memory usage when many tasks fail. A maximum
+// of 0 disables this limit.
+func (sg *ScatterGather[T]) SetMaxErrors(max int) {
+	sg.init(0)
+	sg.errors.MaxErrors = max
+}
+
+// Report errors caused by cancellation of the context, or by an exceeded
+// deadline, only once instead of for every task, so they don't bury the error
+// that caused the cancellation. The number of other cancelled tasks is still
+// reported.
+func (sg *ScatterGather[T]) CollapseCancellations(collapse bool) {
+	sg.init(0)
+	sg.errors.CollapseCancellations = collapse
+}
+
+// Stop starting new tasks once max tasks have failed. Tasks that are skipped
+// because of this are not run at all, and are reported as a single
+// *SkippedError. Tasks that are still waiting to start, for example for a
+// slot, a delay or the rate limiter, stop waiting and are skipped right away.
+// Tasks that are already running are not affected. A maximum of 0 disables
+// this limit.
+func (sg *ScatterGather[T]) SetMaxFailures(max int64) {
+	sg.maxFailures = max
+}
+
+// Configure how the string representation of the returned *ScatteredError is
+// formatted, for example to make it fit on a single log line.
+func (sg *ScatterGather[T]) SetErrorFormatting(formatting ErrorFormatting) {
+	sg.init(0)
+	sg.errors.Formatting = formatting
+}
+
+// When fail-fast mode is enabled, the first task to return an error cancels
+// the context passed to all other tasks, and tasks that have not started yet
+// will not be started at all.
+func (sg *ScatterGather[T]) FailFast(failFast bool) {
+	sg.failFast = failFast
+}
+
+// Stream results as they arrive instead of collecting them for Wait(). This
+// must be called before Wait(). Every result, including failed ones, is sent
+// on the returned channel, preceded by any results that were already gathered
+// before this call. The channel is closed once Wait() has been called and all
+// tasks have completed. As the results have already been consumed, Wait() will
+// then return only the error.
+func (sg *ScatterGather[T]) Results() <-chan Result[T] {
+	sg.init(0)
+	sg.streamLock.Lock()
+	defer sg.streamLock.Unlock()
+	if sg.streamChan == nil {
+		sg.streamChan = make(chan Result[T])
+	}
+	return sg.streamChan
+}
+
+// Iterate over results as they arrive. This replaces the call to Wait(): no
+// more tasks may be submitted once iteration has started. Breaking out of the
+// loop cancels all remaining tasks. The aggregated error is available from
+// Err() afterwards.
+func (sg *ScatterGather[T]) Stream() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		stream := sg.Results()
+		go sg.Wait()
+		for res := range stream {
+			if !yield(res.Value, res.Err) {
+				sg.cancel()
+				go func() {
+					for range stream {
+					}
+				}()
+				return
+			}
+		}
+	}
+}
+
+// Returns the aggregated error of all tasks, like Wait() does. This blocks
+// until all results have been gathered, so it is mostly useful after the
+// channel returned by Results() has been closed.
+func (sg *ScatterGather[T]) Err() error {
+	<-sg.doneChan
+	return sg.err()
+}
+
+// Call callback with the value of every successful task as soon as it has been
+// gathered, without waiting for Wait(). Callbacks are called one at a time,
+// from the goroutine that gathers results, so a slow callback delays the
+// gathering of further results. This must be set before the first task is
+// submitted.
+func (sg *ScatterGather[T]) OnResult(callback func(T)) {
+	sg.onResult = callback
+}
+
+// Call callback with the error of every failed task as soon as it has been
+// gathered, like OnResult does for successful tasks.
+func (sg *ScatterGather[T]) OnError(callback func(error)) {
+	sg.onError = callback
+}
+
+// When panic recovery is enabled, a panic in a task is recovered and turned
+// into a *TaskPanicError for that task, instead of crashing the program.
+func (sg *ScatterGather[T]) RecoverPanics(recoverPanics bool) {
+	sg.recoverPanics = recoverPanics
+}
+
+// Limit the time each task may take. The context passed to a task is
+// cancelled when it has been running for longer than the timeout, so tasks
+// must honor their context for this to have effect. A timeout of 0 disables
+// this limit.
+func (sg *ScatterGather[T]) SetTaskTimeout(timeout time.Duration) {
+	sg.taskTimeout = timeout
+}
+
+// Retry failing tasks up to retries times before reporting their error. The
+// delay between attempts starts at backoff and doubles with every attempt,
+// with random jitter applied to avoid retrying all tasks at the same time.
+// Tasks are not retried once their context is done.
+func (sg *ScatterGather[T]) SetRetry(retries int, backoff time.Duration) {
+	sg.retries = retries
+	sg.retryBackoff = backoff
+}
+
+// Delay the start of every task by a random time up to jitter, to spread out
+// the load when many tasks are submitted at once. The delay is applied before
+// a task waits for a slot, so it does not keep other tasks from running. A
+// jitter of 0 disables this delay.
+func (sg *ScatterGather[T]) SetStartJitter(jitter time.Duration) {
+	sg.startJitter = jitter
+}
+
+// Don't start tasks that have less than duration left before the deadline of
+// their context, as they are not expected to finish in time. Such tasks fail
+// with ErrDeadlineSkipped instead. A duration of 0 disables this check.
+func (sg *ScatterGather[T]) SetMinDuration(duration time.Duration) {
+	sg.minDuration = duration
+}
+
+// Fail tasks that have waited for a slot for longer than timeout with
+// ErrQueueTimeout, to tell being overloaded apart from tasks failing. Once a
+// task has started, this timeout no longer applies. A timeout of 0 disables
+// this limit.
+func (sg *ScatterGather[T]) SetQueueTimeout(timeout time.Duration) {
+	sg.queueTimeout = timeout
+}
+
+// Limit the number of tasks that have been submitted but have not started yet.
+// When this limit is reached, Run blocks until a task starts or its context is
+// done. This must be set before the first task is submitted. A limit of 0
+// disables this limit.
+func (sg *ScatterGather[T]) SetMaxPending(pending int64) {
+	if pending == 0 {
+		sg.pending = nil
+	} else {
+		sg.pending = semaphore.NewWeighted(pending)
+	}
+}
+
+// When a task is still running after the hedge delay, start a duplicate of it
+// if a slot is available, and use the outcome of whichever copy finishes
+// first. The other copy is cancelled, and waited for before the task is
+// considered done, so tasks must honor their context for this to have effect.
+// A delay of 0 disables hedging.
+func (sg *ScatterGather[T]) SetHedgeDelay(delay time.Duration) {
+	sg.hedgeDelay = delay
+}
+
 func (sg *ScatterGather[T]) init(parallel int64) {
 	sg.initOnce.Do(func() {
 		if parallel == 0 {
 			parallel = int64(runtime.GOMAXPROCS(0))
 		}
 		sg.waitGroup = &sync.WaitGroup{}
-		sg.results = make([]T, 0)
 		sg.errors = &ScatteredError{}
-		sg.errors.Errors = make([]error, 0)
-		sg.resultChan = make(chan scatterResult[T], 10)
-		sg.doneChan = make(chan interface{})
+		sg.parallel = parallel
 		sg.semaphore = semaphore.NewWeighted(parallel)
+		sg.parentCtx = context.Background()
+		sg.reset()
 	})
 }
 
+// Prepare the object for a new batch of tasks once Wait() has returned. The
+// configuration is kept, but the results and errors of the previous batch are
+// forgotten. When the object was created with WithContext, the context that
+// was returned is not renewed, but tasks get a fresh group context. A channel
+// passed to GatherInto was closed by the previous batch and is not used again.
+// When the previous batch was aborted, this waits for its tasks to return, so
+// they don't end up in the new batch.
+func (sg *ScatterGather[T]) Reset() {
+	sg.init(0)
+	sg.complete()
+	if _, ok := sg.gatherer.(channelGatherer[T]); ok {
+		// The channel was closed at the end of the previous batch
+		sg.gatherer = nil
+	}
+	errs := *sg.errors
+	errs.Dropped, errs.Cancelled, errs.cancellation = 0, 0, false
+	sg.errors = &errs
+	sg.reset()
+}
+
+// Set up the state for a batch of tasks
+func (sg *ScatterGather[T]) reset() {
+	sg.results = make([]Result[T], 0, sg.expected)
+	sg.errors.Errors = make([]error, 0, sg.expected)
+	sg.resultChan = make(chan Result[T], 10)
+	sg.doneChan = make(chan interface{})
+	sg.gatherOnce = sync.Once{}
+	// A new Once, as WaitResults() may still be completing an aborted batch with
+	// the old one
+	sg.completeOnce = &sync.Once{}
+	sg.finished = false
+	sg.streamChan = nil
+	sg.unstreamed = nil
+	sg.submitted.Store(0)
+	sg.completed, sg.succeeded, sg.quorumChan = 0, 0, nil
+	sg.seen = make(map[any]struct{})
+	sg.merged = -1
+	sg.failures.Store(0)
+	sg.strikes.Store(0)
+	sg.successes.Store(0)
+	sg.running.Store(0)
+	sg.ended.Store(0)
+	sg.halted.Store(false)
+	sg.skipped.Store(0)
+	sg.stopRamp = nil
+	sg.ctx, sg.cancel = context.WithCancel(sg.parentCtx)
+	sg.pendingCtx, sg.cancelPending = context.WithCancel(context.Background())
+	sg.abortChan, sg.abortReason = make(chan struct{}), nil
+	if g, ok := sg.gatherer.(resetter); ok {
+		g.reset()
+	}
+}
+
+// Gatherers set up by this package that keep state for a batch implement this
+// to start over when the ScatterGather is Reset.
+type resetter interface {
+	reset()
+}
+
 func (sg *ScatterGather[T]) gather() {
 	sg.gatherOnce.Do(func() {
-		go sg.gatherer()
+		go sg.gatherResults()
 	})
 }
 
-func (sg *ScatterGather[T]) gatherer() {
+func (sg *ScatterGather[T]) gatherResults() {
 	for res := range sg.resultChan {
-		if res.err != nil {
-			sg.errors.AddError(res.err)
+		sg.resultsLock.Lock()
+		sg.completed++
+		sg.resultsLock.Unlock()
+		if res.skip || (sg.limit > 0 && sg.succeeded >= sg.limit) {
+			continue
+		}
+		if res.Err == nil && sg.resultKey != nil {
+			key := sg.resultKey(res.Value)
+			if _, ok := sg.seen[key]; ok {
+				continue
+			}
+			sg.seen[key] = struct{}{}
+		}
+		sg.resultsLock.Lock()
+		if res.Err != nil {
+			sg.errors.AddError(res.Err)
+		} else {
+			sg.succeeded++
+			if sg.quorumChan != nil && sg.succeeded == sg.quorum {
+				close(sg.quorumChan)
+			}
+			if sg.succeeded == sg.limit {
+				sg.cancel()
+			}
+		}
+		sg.resultsLock.Unlock()
+		if res.Err != nil && sg.onError != nil {
+			sg.onError(res.Err)
+		} else if res.Err == nil && sg.onResult != nil {
+			sg.onResult(res.Value)
+		}
+		if sg.gatherer != nil {
+			if res.Err != nil {
+				sg.gatherer.OnError(res.Err)
+			} else {
+				sg.gatherer.OnResult(res.Value)
+			}
+		} else if stream := sg.stream(); stream != nil {
+			stream <- res
+		} else if !sg.discardResults && (res.Err == nil || sg.keepAllResults) {
+			sg.resultsLock.Lock()
+			if sg.merge != nil && res.Err == nil && sg.merged >= 0 {
+				sg.results[sg.merged].Value = sg.merge(sg.results[sg.merged].Value, res.Value)
+			} else if sg.merge != nil && res.Err == nil {
+				sg.merged = len(sg.results)
+				sg.results = append(sg.results, res)
+			} else if sg.less != nil {
+				i := sort.Search(len(sg.results), func(i int) bool { return sg.less(res.Value, sg.results[i].Value) })
+				sg.results = slices.Insert(sg.results, i, res)
+			} else {
+				sg.results = append(sg.results, res)
+			}
+			sg.resultsLock.Unlock()
+		} else if res.Err != nil {
+			// Failed results are not kept for Wait(), but a stream that is
+			// attached later must still see them
+			sg.resultsLock.Lock()
+			sg.unstreamed = append(sg.unstreamed, res)
+			sg.resultsLock.Unlock()
 		}
-		if res.err == nil || sg.keepAllResults {
-			sg.results = append(sg.results, res.val)
+	}
+	if skipped := sg.skipped.Load(); skipped > 0 {
+		sg.errors.Errors = append(sg.errors.Errors, &SkippedError{Skipped: int(skipped), Err: ErrTooManyFailures})
+	}
+	if sg.gatherer != nil {
+		if err := sg.gatherer.Finish(); err != nil {
+			sg.errors.AddError(err)
 		}
 	}
+	if stream := sg.stream(); stream != nil {
+		close(stream)
+	}
 	close(sg.doneChan)
 }
 
+// Returns the channel results are streamed to, if any. Results gathered before
+// streaming started, including failed ones, are sent first.
+func (sg *ScatterGather[T]) stream() chan Result[T] {
+	sg.streamLock.Lock()
+	stream := sg.streamChan
+	sg.streamLock.Unlock()
+	if stream == nil {
+		return nil
+	}
+	sg.resultsLock.Lock()
+	pending := append(sg.results, sg.unstreamed...)
+	if len(pending) > 0 {
+		sg.results = make([]Result[T], 0)
+		sg.unstreamed = nil
+		sg.merged = -1
+	}
+	sg.resultsLock.Unlock()
+	for _, res := range pending {
+		stream <- res
+	}
+	return stream
+}
+
 // Add a piece of work to be run. This will call the callable in a separate
 // goroutine and pass the context and arguments. The result and error returned
-// by this function will be collected and returned from Wait()
-func (sg *ScatterGather[T]) Run(ctx context.Context, callable func() (T, error)) {
+// by this function will be collected and returned from Wait(). The returned
+// handle can be used to wait for, or cancel, this task alone.
+func (sg *ScatterGather[T]) Run(ctx context.Context, callable func() (T, error)) *Task[T] {
+	return sg.RunContext(ctx, func(context.Context) (T, error) { return callable() })
+}
+
+// Add a piece of work to be run, like Run. The callable is passed the context
+// so it can honor cancellation and deadlines without having to close over it.
+func (sg *ScatterGather[T]) RunContext(ctx context.Context, callable func(context.Context) (T, error)) *Task[T] {
+	return sg.run(&task[T]{ctx: ctx, weight: 1, callable: callable})
+}
+
+// Add a piece of work to be run, like RunContext. The label is attached to
+// the task's Result, and errors returned by the task are wrapped in a
+// *TaskError carrying the label, so failures can be attributed to tasks.
+func (sg *ScatterGather[T]) RunNamed(ctx context.Context, label string, callable func(context.Context) (T, error)) *Task[T] {
+	return sg.run(&task[T]{ctx: ctx, label: label, weight: 1, callable: callable})
+}
+
+// Add a piece of work to be run, like Run. The task occupies weight slots
+// instead of just one, so it counts as weight tasks towards the maximum
+// parallelism. A task that is heavier than the maximum parallelism will not
+// start until the maximum is raised, and fail once its context is done. A
+// negative weight panics.
+func (sg *ScatterGather[T]) RunWeighted(ctx context.Context, weight int64, callable func() (T, error)) *Task[T] {
+	if weight < 0 {
+		panic("scattergather: negative task weight")
+	}
+	return sg.run(&task[T]{ctx: ctx, weight: weight, callable: func(context.Context) (T, error) { return callable() }})
+}
+
+// Add a piece of work to be run, like Run, with a priority. Instead of waiting
+// for a slot in its own goroutine, the task is queued as with UseWorkerPool,
+// and queued tasks with a higher priority are started before those with a
+// lower priority. Tasks with the same priority are started in the order in
+// which they were submitted. Tasks submitted with Run have priority 0.
+func (sg *ScatterGather[T]) RunPriority(ctx context.Context, priority int, callable func() (T, error)) *Task[T] {
+	return sg.run(&task[T]{ctx: ctx, priority: priority, queued: true, weight: 1, callable: func(context.Context) (T, error) { return callable() }})
+}
+
+// Add a piece of work to be run, like Run, that does not start before delay
+// has passed. After the delay, the task waits for a slot like any other task.
+// The delay does not occupy a slot, and the task fails if its context is done
+// before the delay has passed.
+func (sg *ScatterGather[T]) RunAfter(ctx context.Context, delay time.Duration, callable func() (T, error)) *Task[T] {
+	return sg.run(&task[T]{ctx: ctx, delay: delay, weight: 1, callable: func(context.Context) (T, error) { return callable() }})
+}
+
+// Add a piece of work to be run, like Run, that does not start before all of
+// deps have completed successfully. While waiting for them, it does not occupy
+// a slot. If any of them fails, the task is not run, and fails with a
+// *SkippedError wrapping the error of that dependency. When using the worker
+// pool, dependencies must not have a lower priority than the tasks that
+// depend on them.
+func (sg *ScatterGather[T]) RunAfterTasks(ctx context.Context, callable func() (T, error), deps ...*Task[T]) *Task[T] {
+	return sg.run(&task[T]{ctx: ctx, deps: deps, weight: 1, callable: func(context.Context) (T, error) { return callable() }})
+}
+
+// Add a piece of work to be run, like Run, that tries each of fns in order
+// until one of them succeeds, such as a primary data source followed by its
+// backups. The next function is only tried when the previous one failed and
+// the task's context is not done. When all of them fail, the task fails with
+// a *FallbackError holding the error of every function that was tried.
+func (sg *ScatterGather[T]) RunWithFallbacks(ctx context.Context, fns ...func() (T, error)) *Task[T] {
+	return sg.RunContext(ctx, func(ctx context.Context) (ret T, err error) {
+		errs := make([]error, 0, len(fns))
+		for _, fn := range fns {
+			if ret, err = fn(); err == nil {
+				return ret, nil
+			}
+			errs = append(errs, err)
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		return ret, &FallbackError{Errors: errs}
+	})
+}
+
+// Add a batch of work to be run, like calling Run for each of fns, making room
+// for all of their results at once. The returned handles are in the order of
+// fns.
+func (sg *ScatterGather[T]) RunAll(ctx context.Context, fns ...func() (T, error)) []*Task[T] {
 	sg.init(0)
+	sg.reserve(len(fns))
+	tasks := make([]*Task[T], len(fns))
+	for i, fn := range fns {
+		tasks[i] = sg.Run(ctx, fn)
+	}
+	return tasks
+}
+
+// Add a batch of work to be run, calling fn for each of inputs, like RunAll.
+// The returned handles are in the order of inputs.
+func RunEach[A, T any](ctx context.Context, sg *ScatterGather[T], inputs []A, fn func(context.Context, A) (T, error)) []*Task[T] {
+	sg.init(0)
+	sg.reserve(len(inputs))
+	tasks := make([]*Task[T], len(inputs))
+	for i, input := range inputs {
+		tasks[i] = sg.RunContext(ctx, func(ctx context.Context) (T, error) {
+			return fn(ctx, input)
+		})
+	}
+	return tasks
+}
+
+// Add every function received from ch as a piece of work to be run, like Run,
+// until ch is closed or ctx is done, for producers that do not know up front
+// how many tasks there will be. This blocks until then, and returns ctx.Err()
+// if ctx was done first. Use SetMaxPending to stop taking functions from ch
+// while too many tasks are waiting to start.
+func (sg *ScatterGather[T]) RunFromChannel(ctx context.Context, ch <-chan func() (T, error)) error {
+	for {
+		select {
+		case fn, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			sg.Run(ctx, fn)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Add a piece of work to be run for every input received from ch, calling fn
+// with that input, like RunFromChannel.
+func RunEachFromChannel[A, T any](ctx context.Context, sg *ScatterGather[T], ch <-chan A, fn func(context.Context, A) (T, error)) error {
+	for {
+		select {
+		case input, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			sg.RunContext(ctx, func(ctx context.Context) (T, error) {
+				return fn(ctx, input)
+			})
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Make room for n more results, unless they are not collected
+func (sg *ScatterGather[T]) reserve(n int) {
+	if sg.discardResults || sg.merge != nil || sg.gatherer != nil {
+		return
+	}
+	sg.resultsLock.Lock()
+	sg.results = slices.Grow(sg.results, n)
+	sg.resultsLock.Unlock()
+}
+
+// Add a piece of work to be run, like Run, but only if it can be started
+// immediately, or queued when SetMaxPending is used. Returns whether the task
+// was accepted. This is useful for shedding load rather than queueing it.
+func (sg *ScatterGather[T]) TryRun(ctx context.Context, callable func() (T, error)) bool {
+	sg.init(0)
+	t := &task[T]{ctx: ctx, weight: 1, callable: func(context.Context) (T, error) { return callable() }}
+	if sg.pending != nil {
+		if !sg.pending.TryAcquire(1) {
+			return false
+		}
+		t.pending = true
+	} else {
+		if !sg.semaphore.TryAcquire(1) {
+			return false
+		}
+		t.acquired = true
+	}
+	sg.admit(t)
+	sg.dispatch(t)
+	return true
+}
+
+func (sg *ScatterGather[T]) run(t *task[T]) *Task[T] {
+	sg.init(0)
+	t.handle = newTask(t)
+	if sg.pending != nil {
+		if err := sg.pending.Acquire(t.ctx, 1); err != nil {
+			var zero T
+			sg.admit(t)
+			sg.finish(t, zero, err)
+			return t.handle
+		}
+		t.pending = true
+	}
+	sg.admit(t)
+	sg.dispatch(t)
+	return t.handle
+}
+
+// Register a task with the group, so Wait() will wait for it
+func (sg *ScatterGather[T]) admit(t *task[T]) {
 	sg.gather()
+	if sg.trackProcs {
+		if procs := int64(runtime.GOMAXPROCS(0)); sg.procs.Swap(procs) != procs {
+			sg.SetParallel(procs)
+		}
+	}
+	sg.stateLock.Lock()
+	if sg.closed {
+		sg.stateLock.Unlock()
+		panic(ErrClosed)
+	}
+	if sg.finished && sg.runningTask(t.ctx) == nil {
+		sg.stateLock.Unlock()
+		panic(ErrAlreadyFinished)
+	}
 	sg.waitGroup.Add(1)
-	go func() {
-		defer sg.waitGroup.Done()
-		if err := sg.semaphore.Acquire(ctx, 1); err != nil {
-			sg.resultChan <- scatterResult[T]{err: err}
-			return
+	sg.stateLock.Unlock()
+	t.index = int(sg.submitted.Add(1) - 1)
+	if t.index == 0 && sg.rampUp > 0 {
+		sg.queueLock.Lock()
+		target := sg.parallel
+		sg.queueLock.Unlock()
+		if target > 1 {
+			sg.startRamp(target)
 		}
-		defer sg.semaphore.Release(1)
-		ret, err := callable()
-		sg.resultChan <- scatterResult[T]{val: ret, err: err}
-	}()
+	}
+}
+
+// Start executing an admitted task, or queue it for the worker pool
+func (sg *ScatterGather[T]) dispatch(t *task[T]) {
+	if sg.workerPool || t.queued {
+		sg.enqueue(t)
+	} else {
+		sg.lineUp(t)
+		go sg.runTask(t)
+	}
+}
+
+// Take a place in line for a slot, so tasks get their slots in the order in
+// which they were submitted rather than in the order in which their goroutines
+// happen to run. Tasks that have to wait for something else before they may
+// take a slot take their place once they are done waiting.
+func (sg *ScatterGather[T]) lineUp(t *task[T]) {
+	if t.acquired || t.delay > 0 || len(t.deps) > 0 || sg.breaker != nil || sg.startJitter > 0 || (t.keyed && sg.keys != nil) {
+		return
+	}
+	t.reserved = sg.semaphore.Reserve(t.weight)
+}
+
+func (sg *ScatterGather[T]) runTask(t *task[T]) {
+	ret, err := sg.execute(t)
+	sg.finish(t, ret, err)
+}
+
+// Send the outcome of a task to the gatherer
+func (sg *ScatterGather[T]) finish(t *task[T], ret T, err error) {
+	defer sg.waitGroup.Done()
+	res := Result[T]{Value: ret, Index: t.index, Label: t.label}
+	if err == errSkipped {
+		res.skip = true
+		sg.skipped.Add(1)
+	} else if err != nil {
+		class := sg.classify(err)
+		if class == ErrorIgnore {
+			res.skip = true
+		} else {
+			res.Err = &TaskError{Index: t.index, Label: t.label, Err: err}
+		}
+		if class == ErrorAbort || (sg.failFast && class != ErrorIgnore) {
+			sg.cancel()
+		}
+		if class != ErrorIgnore {
+			sg.failures.Add(1)
+		}
+	} else {
+		sg.successes.Add(1)
+	}
+	sg.ended.Add(1)
+	if t.started {
+		sg.running.Add(-1)
+	}
+	if sg.onEvent != nil {
+		event := Event{Type: TaskFinished, Index: t.index, Label: t.label, Err: res.Err}
+		if t.started {
+			event.Duration = time.Since(t.startTime)
+		}
+		sg.onEvent(event)
+	}
+	if t.handle != nil && err == errSkipped {
+		// Skipped tasks are counted rather than gathered, but their handles
+		// must not look like they succeeded
+		handleRes := res
+		handleRes.Err = &SkippedError{Skipped: 1, Err: ErrTooManyFailures}
+		t.handle.finish(handleRes)
+	} else if t.handle != nil {
+		t.handle.finish(res)
+	}
+	sg.resultChan <- res
+}
+
+// Execute a task once a slot is available, unless its context is done before
+// that time.
+func (sg *ScatterGather[T]) execute(t *task[T]) (ret T, err error) {
+	ctx, cancel := sg.taskContext(t.ctx)
+	defer cancel()
+	waitCtx, stopWaiting := sg.waitContext(ctx)
+	defer stopWaiting()
+	if sg.halted.Load() {
+		err = errSkipped
+	} else if t.waitErr != nil {
+		err = t.waitErr
+	} else {
+		err = sg.waitToStart(waitCtx, t)
+	}
+	if err == nil && t.keyed && sg.keys != nil {
+		if err = sg.keys.acquire(waitCtx, t.key); err == nil {
+			defer sg.keys.release(t.key)
+		}
+	}
+	if err == nil && sg.halted.Load() {
+		err = errSkipped
+	}
+	if err == nil && !t.acquired {
+		err = sg.acquire(waitCtx, t)
+	} else if err != nil && t.acquired {
+		sg.semaphore.Release(t.weight)
+	} else if err != nil && t.reserved != nil {
+		t.reserved.Cancel()
+	}
+	if t.pending {
+		sg.pending.Release(1)
+	}
+	if err != nil {
+		err = sg.waitError(ctx, err)
+		sg.countFailure(err)
+		return ret, err
+	}
+	defer sg.semaphore.Release(t.weight)
+	// Count a failure before the slot is released, so no other task can take
+	// the slot before the group is halted
+	defer func() { sg.countFailure(err) }()
+	if sg.halted.Load() {
+		return ret, errSkipped
+	}
+	if sg.pendingCtx.Err() != nil {
+		return ret, ErrPendingCancelled
+	}
+	if err = ctx.Err(); err != nil {
+		return ret, err
+	}
+	if sg.limiter != nil {
+		if err = sg.limiter.Wait(waitCtx); err != nil {
+			return ret, sg.waitError(ctx, err)
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok && sg.minDuration > 0 && time.Until(deadline) < sg.minDuration {
+		return ret, ErrDeadlineSkipped
+	}
+	t.started = true
+	t.startTime = time.Now()
+	sg.running.Add(1)
+	if sg.onEvent != nil {
+		sg.onEvent(Event{Type: TaskStarted, Index: t.index, Label: t.label})
+	}
+	ctx, rt := sg.markRunning(ctx, t)
+	if sg.hedgeDelay > 0 {
+		ret, err = sg.hedge(ctx, t.callable)
+	} else {
+		ret, err = sg.call(ctx, t.callable)
+	}
+	rt.running.Store(false)
+	if sg.breaker != nil {
+		sg.breaker.record(err != nil && !errors.Is(err, ErrSkip))
+	}
+	if sg.adaptive != nil {
+		if parallel, changed := sg.adaptive.record(t.startTime, err != nil && !errors.Is(err, ErrSkip)); changed {
+			sg.SetParallel(parallel)
+		}
+	}
+	return ret, err
+}
+
+// Wait until a task may take its place in line for a slot: until the circuit
+// breaker is closed, its dependencies are done and its start delay and jitter
+// have passed. Only the circuit breaker is checked again for a task that has
+// waited already.
+func (sg *ScatterGather[T]) waitToStart(ctx context.Context, t *task[T]) error {
+	if sg.breaker != nil {
+		if err := sg.breaker.wait(ctx); err != nil {
+			return err
+		}
+	}
+	if t.acquired || t.waited {
+		return nil
+	}
+	if len(t.deps) > 0 {
+		if err := awaitDependencies(ctx, t.deps); err != nil {
+			return err
+		}
+	}
+	if t.delay > 0 {
+		if err := sleep(ctx, t.delay); err != nil {
+			return err
+		}
+	}
+	if sg.startJitter > 0 {
+		return sleep(ctx, time.Duration(rand.Int63n(int64(sg.startJitter))))
+	}
+	return nil
+}
+
+// Count a failed task, and stop starting new tasks once too many have failed
+func (sg *ScatterGather[T]) countFailure(err error) {
+	if err == nil || err == errSkipped || sg.classify(err) == ErrorIgnore {
+		return
+	}
+	if strikes := sg.strikes.Add(1); sg.maxFailures > 0 && strikes >= sg.maxFailures {
+		sg.halted.Store(true)
+		// Wake up tasks that are waiting to start, so they are skipped
+		sg.cancelPending()
+	}
+}
+
+// Call a task's callable, starting a duplicate when it takes too long
+func (sg *ScatterGather[T]) hedge(ctx context.Context, callable func(context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan Result[T], 2)
+	run := func() {
+		ret, err := sg.call(ctx, callable)
+		results <- Result[T]{Value: ret, Err: err}
+	}
+	go run()
+	timer := time.NewTimer(sg.hedgeDelay)
+	defer timer.Stop()
+	select {
+	case res := <-results:
+		return res.Value, res.Err
+	case <-timer.C:
+	}
+	if !sg.semaphore.TryAcquire(1) {
+		res := <-results
+		return res.Value, res.Err
+	}
+	defer sg.semaphore.Release(1)
+	go run()
+	res := <-results
+	cancel()
+	<-results
+	return res.Value, res.Err
+}
+
+// Call a task's callable, retrying it if so configured
+func (sg *ScatterGather[T]) call(ctx context.Context, callable func(context.Context) (T, error)) (T, error) {
+	ret, err := sg.attempt(ctx, callable)
+	for i := 0; err != nil && i < sg.retries && ctx.Err() == nil && sg.classify(err) == ErrorRetry; i++ {
+		timer := time.NewTimer(sg.backoff(i))
+		select {
+		case <-timer.C:
+			ret, err = sg.attempt(ctx, callable)
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+	return ret, err
+}
+
+// Wait for a slot for a task, until ctx is done or the queue timeout has
+// passed. Tasks that have not taken their place in line yet do so now.
+func (sg *ScatterGather[T]) acquire(ctx context.Context, t *task[T]) error {
+	if t.reserved == nil {
+		t.reserved = sg.semaphore.Reserve(t.weight)
+	}
+	if sg.queueTimeout == 0 {
+		return t.reserved.Wait(ctx)
+	}
+	queueCtx, cancel := context.WithTimeout(ctx, sg.queueTimeout)
+	defer cancel()
+	err := t.reserved.Wait(queueCtx)
+	if err != nil && ctx.Err() == nil {
+		return ErrQueueTimeout
+	}
+	return err
+}
+
+// Wait for the given time, or until ctx is done
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// The delay before the given retry of a task, with jitter applied
+func (sg *ScatterGather[T]) backoff(retry int) time.Duration {
+	backoff := sg.retryBackoff * time.Duration(1<<min(retry, 16))
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// Make a single attempt at calling a task's callable, applying the task
+// timeout and recovering from panics if so configured
+func (sg *ScatterGather[T]) attempt(ctx context.Context, callable func(context.Context) (T, error)) (ret T, err error) {
+	if sg.taskTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, sg.taskTimeout)
+		defer cancel()
+	}
+	if sg.recoverPanics {
+		defer func() {
+			if r := recover(); r != nil {
+				err = &TaskPanicError{Value: r, Stack: debug.Stack()}
+			}
+		}()
+	}
+	return callable(ctx)
+}
+
+// Derive the context for a single task from the context passed to Run. This
+// context is also cancelled when the group itself is cancelled.
+func (sg *ScatterGather[T]) taskContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return linkContext(ctx, sg.ctx)
+}
+
+// Derive the context a task waits with before it starts. This context is also
+// cancelled when CancelPending is called.
+func (sg *ScatterGather[T]) waitContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return linkContext(ctx, sg.pendingCtx)
+}
+
+// The error for a task that failed before it started. Tasks cancelled by
+// CancelPending fail with ErrPendingCancelled, and tasks that stopped waiting
+// because too many tasks failed are skipped, rather than failing with a
+// context error.
+func (sg *ScatterGather[T]) waitError(ctx context.Context, err error) error {
+	if ctx.Err() == nil && sg.halted.Load() {
+		return errSkipped
+	}
+	if ctx.Err() == nil && sg.pendingCtx.Err() != nil {
+		return ErrPendingCancelled
+	}
+	return err
+}
+
+// Derive a context from ctx that is also cancelled when other is done
+func linkContext(ctx, other context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(other, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
 }
 
 // Wait for all subtasks to return. The return value is a list of values
 // returned from all subtasks, excluding any nil that was returned. The
 // returned error is either `nil` to indicate no subtask returned an error or a
-// *ScatteredError containing all errors returned by subtasks.
+// *ScatteredError containing all errors returned by subtasks. Wait may be
+// called more than once, and returns the same results and error every time.
 func (sg *ScatterGather[T]) Wait() ([]T, error) {
+	results, err := sg.WaitResults()
+	return values(results), err
+}
+
+// Wait for all subtasks to return, like Wait, and append their values to dst
+// instead of to a newly allocated slice. The extended slice is returned, as
+// with append, so buffers can be reused across batches.
+func (sg *ScatterGather[T]) WaitAppend(dst []T) ([]T, error) {
+	results, err := sg.WaitResults()
+	dst = slices.Grow(dst, len(results))
+	for _, res := range results {
+		dst = append(dst, res.Value)
+	}
+	return dst, err
+}
+
+// Wait for all subtasks to return, like Wait, for tasks that return slices.
+// The slices are concatenated into a single slice, in the order in which the
+// results were returned by Wait.
+func WaitFlat[E any](sg *ScatterGather[[]E]) ([]E, error) {
+	results, err := sg.Wait()
+	size := 0
+	for _, result := range results {
+		size += len(result)
+	}
+	flat := make([]E, 0, size)
+	for _, result := range results {
+		flat = append(flat, result...)
+	}
+	return flat, err
+}
+
+// Wait until n subtasks have returned successfully, and cancel the remaining
+// ones. When enough subtasks succeed, the first n values are returned without
+// an error. Otherwise this returns like Wait.
+func (sg *ScatterGather[T]) WaitN(n int) ([]T, error) {
+	quorum := make(chan struct{})
+	sg.resultsLock.Lock()
+	sg.quorum = n
+	sg.quorumChan = quorum
+	if sg.succeeded >= n {
+		close(quorum)
+	}
+	sg.resultsLock.Unlock()
+	done := make(chan struct{})
+	go func() {
+		sg.waitGroup.Wait()
+		close(done)
+	}()
+	select {
+	case <-quorum:
+		sg.cancel()
+	case <-done:
+	}
+	results, err := sg.WaitResults()
+	if sg.succeeded < n {
+		return values(results), err
+	}
+	successes := make([]T, 0, n)
+	for _, res := range results {
+		if res.Err == nil && len(successes) < n {
+			successes = append(successes, res.Value)
+		}
+	}
+	return successes, nil
+}
+
+// Wait for the first subtask to return successfully, and cancel the remaining
+// ones. The value returned by that subtask is returned. Only when all subtasks
+// fail is an error returned, which is a *ScatteredError containing all errors.
+// This is useful for racing requests to several equivalent endpoints.
+func (sg *ScatterGather[T]) WaitFirst() (T, error) {
+	results, err := sg.WaitN(1)
+	if err != nil || len(results) == 0 {
+		var zero T
+		return zero, err
+	}
+	return results[0], nil
+}
+
+// Wait for all subtasks to return, like Wait, or until ctx is done. In the
+// latter case, the results gathered so far are returned, and the returned
+// *ScatteredError contains the errors gathered so far and an
+// *IncompleteError. Tasks keep running, and Wait() can be called later to
+// gather all results.
+func (sg *ScatterGather[T]) WaitContext(ctx context.Context) ([]T, error) {
+	done := make(chan struct{})
+	go func() {
+		sg.waitGroup.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return sg.Wait()
+	case <-ctx.Done():
+	}
+	results, errs, outstanding := sg.snapshot()
+	errs.Errors = append(errs.Errors, &IncompleteError{Outstanding: outstanding, Err: ctx.Err()})
+	return values(results), sg.aggregate(errs)
+}
+
+// Returns a copy of the values gathered so far, without waiting for the
+// remaining subtasks. This can be used to display the progress of a long
+// running batch.
+func (sg *ScatterGather[T]) Snapshot() []T {
+	sg.init(0)
+	results, _, _ := sg.snapshot()
+	return values(results)
+}
+
+// Returns copies of the results and errors gathered so far, and the number of
+// tasks that have not finished yet
+func (sg *ScatterGather[T]) snapshot() ([]Result[T], *ScatteredError, int) {
+	sg.resultsLock.Lock()
+	results := make([]Result[T], len(sg.results))
+	copy(results, sg.results)
+	errs := sg.errors.clone()
+	outstanding := int(sg.submitted.Load()) - sg.completed
+	sg.resultsLock.Unlock()
+	if sg.orderedResults && sg.less == nil {
+		sortResults(results)
+	}
+	return results, errs, outstanding
+}
+
+// Wait for all subtasks to return, like Wait. Instead of just the values, a
+// Result is returned for every subtask so that, when KeepAllResults is
+// enabled, failures can be correlated with the tasks that caused them.
+func (sg *ScatterGather[T]) WaitResults() ([]Result[T], error) {
+	sg.init(0)
+	done := make(chan struct{})
+	// The goroutine may only get going after an aborted batch has been Reset,
+	// so it must not pick up the next batch
+	once := sg.completeOnce
+	go func() {
+		once.Do(sg.completeBatch)
+		close(done)
+	}()
+	select {
+	case <-done:
+		if sg.aborted() == nil {
+			return sg.results, sg.err()
+		}
+	case <-sg.abortChan:
+	}
+	results, errs, outstanding := sg.snapshot()
+	errs.Errors = append(errs.Errors, &IncompleteError{Outstanding: outstanding, Err: sg.aborted()})
+	return results, sg.aggregate(errs)
+}
+
+// Wait for all tasks in the current batch to finish and for their results to
+// be gathered. This is only done once per batch.
+func (sg *ScatterGather[T]) complete() {
+	sg.init(0)
+	sg.completeOnce.Do(sg.completeBatch)
+}
+
+func (sg *ScatterGather[T]) completeBatch() {
+	sg.gather()
+	sg.stateLock.Lock()
+	sg.finished = true
+	sg.stateLock.Unlock()
 	sg.waitGroup.Wait()
+	if sg.stopRamp != nil {
+		sg.stopRamp()
+	}
 	close(sg.resultChan)
 	<-sg.doneChan
-	if !sg.errors.HasErrors() {
-		return sg.results, nil
+	sg.cancel()
+	if sg.orderedResults && sg.less == nil {
+		sg.resultsLock.Lock()
+		sortResults(sg.results)
+		sg.resultsLock.Unlock()
+	}
+}
+
+// Abort the current batch of tasks: cancel the context of all running tasks,
+// cancel all tasks that have not started yet as CancelPending does, and make
+// Wait() return right away, without waiting for running tasks to return. Wait()
+// then returns the results gathered so far, and an *IncompleteError wrapping
+// reason. Only the reason passed to the first call is kept.
+func (sg *ScatterGather[T]) Abort(reason error) {
+	sg.init(0)
+	sg.stateLock.Lock()
+	if sg.abortReason == nil {
+		sg.abortReason = reason
+		close(sg.abortChan)
+	}
+	sg.stateLock.Unlock()
+	sg.cancelPending()
+	sg.cancel()
+}
+
+// The reason the current batch was aborted, if it was
+func (sg *ScatterGather[T]) aborted() error {
+	sg.stateLock.Lock()
+	defer sg.stateLock.Unlock()
+	return sg.abortReason
+}
+
+// Cancel all tasks that have not started yet, without running them, but let
+// running tasks finish. The cancelled tasks fail with ErrPendingCancelled.
+// Tasks that are submitted afterwards are cancelled as well, until Reset is
+// called.
+func (sg *ScatterGather[T]) CancelPending() {
+	sg.init(0)
+	sg.cancelPending()
+}
+
+// Cancel all outstanding tasks and wait for them to return, releasing all
+// resources. Once closed, no more tasks may be submitted. This can safely be
+// deferred, also when Wait() has been called.
+func (sg *ScatterGather[T]) Close() {
+	sg.init(0)
+	sg.stateLock.Lock()
+	sg.closed = true
+	sg.stateLock.Unlock()
+	sg.cancel()
+	sg.complete()
+}
+
+// The error returned to the caller once all results have been gathered
+func (sg *ScatterGather[T]) err() error {
+	if sg.limit > 0 && sg.succeeded >= sg.limit {
+		return nil
 	}
-	return sg.results, sg.errors
+	return sg.aggregate(sg.errors)
+}
+
+// Turn a collection of errors into the error returned to the caller, which is
+// nil if there are no errors.
+func (sg *ScatterGather[T]) aggregate(errs *ScatteredError) error {
+	if !errs.HasErrors() {
+		return nil
+	}
+	if sg.flattenErrors {
+		errs = errs.Flatten()
+	}
+	if sg.joinErrors {
+		return errors.Join(errs.Errors...)
+	}
+	return errs
+}
+
+// Sort results by submission order
+func sortResults[T any](results []Result[T]) {
+	sort.Slice(results, func(i, j int) bool { return results[i].Index < results[j].Index })
+}
+
+// Extract the values from a list of results
+func values[T any](results []Result[T]) []T {
+	values := make([]T, len(results))
+	for i, res := range results {
+		values[i] = res.Value
+	}
+	return values
 }
 
 // An error type that represents a collection of errors
 type ScatteredError struct {
 	Errors []error
+	// When set, identical error messages are only shown once by Error(),
+	// with the number of times they occurred
+	Deduplicate bool
+	// When set, at most this many errors are stored. Further errors are only
+	// counted in Dropped.
+	MaxErrors int
+	Dropped   int
+	// When set, only the first error caused by a cancelled context or an
+	// exceeded deadline is stored. Further such errors are only counted in
+	// Cancelled.
+	CollapseCancellations bool
+	Cancelled             int
+	cancellation          bool
+	// How Error() formats the collection
+	Formatting ErrorFormatting
+}
+
+// Options for the string representation of a *ScatteredError
+type ErrorFormatting struct {
+	// The separator between errors, a newline by default
+	Separator string
+	// Prefix the errors with the total number of errors
+	CountPrefix bool
+	// When set, at most this many errors are shown, followed by the number of
+	// errors that are not shown
+	MaxShown int
 }
 
 // Whether any errors have been added to this object
@@ -121,6 +1479,17 @@ func (e *ScatteredError) HasErrors() bool {
 
 // Add an error to the collection
 func (e *ScatteredError) AddError(err error) {
+	if e.CollapseCancellations && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		if e.cancellation {
+			e.Cancelled++
+			return
+		}
+		e.cancellation = true
+	}
+	if e.MaxErrors > 0 && len(e.Errors) >= e.MaxErrors {
+		e.Dropped++
+		return
+	}
 	if e.Errors == nil {
 		e.Errors = []error{err}
 	} else {
@@ -136,23 +1505,324 @@ func (e *ScatteredError) Error() string {
 	if e.Errors == nil || len(e.Errors) == 0 {
 		return "(empty scattered error)"
 	}
-	errstr := e.Errors[0].Error()
-	for _, err := range e.Errors[1:] {
-		errstr += "\n" + err.Error()
+	var messages []string
+	if e.Deduplicate {
+		messages = e.deduplicatedMessages()
+	} else {
+		messages = make([]string, len(e.Errors))
+		for i, err := range e.Errors {
+			messages[i] = err.Error()
+		}
+	}
+	if max := e.Formatting.MaxShown; max > 0 && len(messages) > max {
+		messages = append(messages[:max], fmt.Sprintf("... and %d more", len(messages)-max))
+	}
+	if e.Cancelled > 0 {
+		messages = append(messages, fmt.Sprintf("... and %d more tasks cancelled", e.Cancelled))
+	}
+	if e.Dropped > 0 {
+		messages = append(messages, fmt.Sprintf("... and %d more errors", e.Dropped))
+	}
+	separator := e.Formatting.Separator
+	if separator == "" {
+		separator = "\n"
+	}
+	errstr := strings.Join(messages, separator)
+	if e.Formatting.CountPrefix {
+		errstr = fmt.Sprintf("%d tasks failed: %s", len(e.Errors)+e.Cancelled+e.Dropped, errstr)
 	}
 	return errstr
 }
 
-// ScatteredErrors are identical iff the errors in their collections are identical
+// Returns a new collection in which all nested *ScatteredErrors, such as those
+// returned by tasks that run their own scatter/gather operations, are merged
+// into one flat list. Errors from a nested collection returned by a task are
+// each wrapped in a *TaskError for that task, to preserve its index and label.
+func (e *ScatteredError) Flatten() *ScatteredError {
+	flat := *e
+	flat.Errors = make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		flat.flatten(err)
+	}
+	return &flat
+}
+
+func (e *ScatteredError) flatten(err error) {
+	switch err := err.(type) {
+	case *ScatteredError:
+		e.Dropped += err.Dropped
+		e.Cancelled += err.Cancelled
+		for _, inner := range err.Errors {
+			e.flatten(inner)
+		}
+	case *TaskError:
+		nested, ok := err.Err.(*ScatteredError)
+		if !ok {
+			e.Errors = append(e.Errors, err)
+			return
+		}
+		nested = nested.Flatten()
+		e.Dropped += nested.Dropped
+		e.Cancelled += nested.Cancelled
+		for _, inner := range nested.Errors {
+			e.Errors = append(e.Errors, &TaskError{Index: err.Index, Label: err.Label, Err: inner})
+		}
+	default:
+		e.Errors = append(e.Errors, err)
+	}
+}
+
+// Returns the number of times each error message occurs in the collection.
+// Task labels are not included in the messages, so identical failures of
+// different tasks are counted together. Collapsed cancellation errors are
+// counted too, but dropped errors are not.
+func (e *ScatteredError) Summary() map[string]int {
+	summary := make(map[string]int)
+	for _, err := range e.Errors {
+		if terr, ok := err.(*TaskError); ok {
+			err = terr.Err
+		}
+		summary[err.Error()]++
+		if e.Cancelled > 0 && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+			summary[err.Error()] += e.Cancelled
+		}
+	}
+	return summary
+}
+
+// Make a copy of the collection that can be modified independently
+func (e *ScatteredError) clone() *ScatteredError {
+	c := *e
+	c.Errors = make([]error, len(e.Errors))
+	copy(c.Errors, e.Errors)
+	return &c
+}
+
+func (e *ScatteredError) deduplicatedMessages() []string {
+	messages := make([]string, 0)
+	counts := make(map[string]int)
+	for _, err := range e.Errors {
+		msg := err.Error()
+		if counts[msg] == 0 {
+			messages = append(messages, msg)
+		}
+		counts[msg]++
+	}
+	for i, msg := range messages {
+		if counts[msg] > 1 {
+			messages[i] = fmt.Sprintf("%s (%d times)", msg, counts[msg])
+		}
+	}
+	return messages
+}
+
+// Formats the error. The %+v verb prints every error in the collection on its
+// own line, in verbose form, including task labels and stack traces of
+// panicking tasks. Other verbs print the compact form returned by Error().
+func (e *ScatteredError) Format(f fmt.State, verb rune) {
+	if verb != 'v' || !f.Flag('+') || !e.HasErrors() {
+		formatError(f, verb, e)
+		return
+	}
+	fmt.Fprintf(f, "%d errors:", len(e.Errors))
+	for _, err := range e.Errors {
+		fmt.Fprintf(f, "\n%+v", err)
+	}
+}
+
+// Format an error for verbs that don't have a verbose form
+func formatError(f fmt.State, verb rune, err error) {
+	switch verb {
+	case 'q':
+		fmt.Fprintf(f, "%q", err.Error())
+	default:
+		io.WriteString(f, err.Error())
+	}
+}
+
+// ScatteredErrors are identical iff the errors in their collections are
+// identical. An error in e matches the error at the same position in target if
+// it is, or wraps, that error. This way the *ScatteredError returned by Wait(),
+// whose errors are wrapped in a *TaskError, matches a *ScatteredError of the
+// errors the tasks returned.
 func (e *ScatteredError) Is(target error) bool {
 	t, ok := target.(*ScatteredError)
 	if !ok || len(e.Errors) != len(t.Errors) {
 		return false
 	}
 	for i, err := range t.Errors {
-		if !errors.Is(err, e.Errors[i]) {
+		if !errors.Is(e.Errors[i], err) {
 			return false
 		}
 	}
 	return true
 }
+
+// Returns all errors in the collection, so errors.Is and errors.As can find
+// errors inside it
+func (e *ScatteredError) Unwrap() []error {
+	return e.Errors
+}
+
+// Returns all errors of type E found in err, like errors.As does for a single
+// error. The whole error tree is searched, including nested *ScatteredErrors,
+// but errors wrapped by a matching error are not.
+func ErrorsAs[E error](err error) []E {
+	found := make([]E, 0)
+	var walk func(error)
+	walk = func(err error) {
+		if e, ok := err.(E); ok {
+			found = append(found, e)
+			return
+		}
+		switch err := err.(type) {
+		case interface{ Unwrap() error }:
+			if inner := err.Unwrap(); inner != nil {
+				walk(inner)
+			}
+		case interface{ Unwrap() []error }:
+			for _, inner := range err.Unwrap() {
+				walk(inner)
+			}
+		}
+	}
+	if err != nil {
+		walk(err)
+	}
+	return found
+}
+
+// An error indicating that not all tasks had finished when waiting stopped,
+// wrapping the error that caused waiting to stop
+type IncompleteError struct {
+	Outstanding int
+	Err         error
+}
+
+func (e *IncompleteError) Error() string {
+	return fmt.Sprintf("%d tasks still outstanding: %v", e.Outstanding, e.Err)
+}
+
+func (e *IncompleteError) Unwrap() error {
+	return e.Err
+}
+
+// The value Run and its variants panic with when called after Close
+var ErrClosed = errors.New("scattergather: task submitted after Close")
+
+// The value Run and its variants panic with when called after Wait, without
+// calling Reset first
+var ErrAlreadyFinished = errors.New("scattergather: task submitted after Wait")
+
+// Returned by Wait when tasks were skipped after too many tasks failed
+var ErrTooManyFailures = errors.New("too many failures")
+
+// Tasks can return this error to indicate that they have no result, without
+// having failed. Such tasks produce neither a result nor an error.
+var ErrSkip = errors.New("scattergather: no result")
+
+// Returned for tasks that were not started because too little time was left
+// before the deadline of their context. See SetMinDuration.
+var ErrDeadlineSkipped = errors.New("scattergather: too little time left before the deadline")
+
+// Returned for tasks that waited too long for a slot. See SetQueueTimeout.
+var ErrQueueTimeout = errors.New("scattergather: timed out waiting for a slot")
+
+// Returned for tasks that were cancelled by CancelPending before they started
+var ErrPendingCancelled = errors.New("scattergather: task cancelled before it started")
+
+// Internal marker for tasks that were skipped instead of run
+var errSkipped = errors.New("task skipped")
+
+// An error reporting the number of tasks that were skipped without being run,
+// wrapping the reason for skipping them
+type SkippedError struct {
+	Skipped int
+	Err     error
+}
+
+func (e *SkippedError) Error() string {
+	return fmt.Sprintf("%d tasks skipped: %v", e.Skipped, e.Err)
+}
+
+func (e *SkippedError) Unwrap() error {
+	return e.Err
+}
+
+// The error of a task submitted with RunWithFallbacks when all functions
+// failed, holding their errors in the order in which they were tried
+type FallbackError struct {
+	Errors []error
+}
+
+func (e *FallbackError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return "all fallbacks failed: " + strings.Join(messages, ", ")
+}
+
+func (e *FallbackError) Unwrap() []error {
+	return e.Errors
+}
+
+// An error returned by a task, carrying the index and label of that task so it
+// can be mapped back to the input that produced it. All errors returned by
+// tasks are wrapped in a TaskError.
+type TaskError struct {
+	Index int
+	Label string
+	Err   error
+}
+
+func (e *TaskError) Error() string {
+	if e.Label == "" {
+		return e.Err.Error()
+	}
+	return e.Label + ": " + e.Err.Error()
+}
+
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// Formats the error. The %+v verb includes the task index and formats the
+// wrapped error verbosely too.
+func (e *TaskError) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('+') {
+		if e.Label == "" {
+			fmt.Fprintf(f, "task %d: %+v", e.Index, e.Err)
+		} else {
+			fmt.Fprintf(f, "task %d (%s): %+v", e.Index, e.Label, e.Err)
+		}
+		return
+	}
+	formatError(f, verb, e)
+}
+
+// An error representing a panic in a task, containing the value passed to
+// panic() and the stack trace of the panicking goroutine.
+type TaskPanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *TaskPanicError) Error() string {
+	return fmt.Sprintf("task panicked: %v", e.Value)
+}
+
+// Formats the error. The %+v verb includes the stack trace of the panic.
+func (e *TaskPanicError) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('+') {
+		fmt.Fprintf(f, "%s\n%s", e.Error(), e.Stack)
+		return
+	}
+	formatError(f, verb, e)
+}
+
+// If the task panicked with an error, that error is returned
+func (e *TaskPanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
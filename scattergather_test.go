@@ -2,13 +2,17 @@ package scattergather
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"runtime"
 	"sort"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/seveas/scattergather/semaphore"
 	"github.com/stretchr/testify/assert"
-	"golang.org/x/sync/semaphore"
 )
 
 func TestScatteredError(t *testing.T) {
@@ -18,6 +22,133 @@ func TestScatteredError(t *testing.T) {
 	assert.False(t, e.HasErrors(), "empty ScatteredError has no errors")
 }
 
+func TestScatteredErrorUnwrap(t *testing.T) {
+	e := &ScatteredError{}
+	e.AddError(fmt.Errorf("oops"))
+	e.AddError(&TaskError{Label: "task", Err: &cantEven{}})
+	assert.ErrorIs(t, e, &cantEven{}, "errors.Is finds wrapped errors")
+	var terr *TaskError
+	assert.ErrorAs(t, e, &terr, "errors.As finds wrapped errors")
+	assert.Equal(t, "task", terr.Label)
+	assert.NotErrorIs(t, e, context.Canceled)
+}
+
+func TestDeduplicateErrors(t *testing.T) {
+	sg := New[int](0)
+	sg.DeduplicateErrors(true)
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		sg.Run(ctx, squareOdds(i))
+	}
+	sg.Run(ctx, func() (int, error) { return 0, fmt.Errorf("oops") })
+	_, err := sg.Wait()
+	assert.Equal(t, 6, len(err.(*ScatteredError).Errors), "All errors are kept")
+	assert.ElementsMatch(t, []string{"I can't even (5 times)", "oops"}, strings.Split(err.Error(), "\n"), "Identical errors are collapsed")
+}
+
+func TestTaskError(t *testing.T) {
+	sg := New[int](0)
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		sg.Run(ctx, squareOdds(i))
+	}
+	_, err := sg.Wait()
+	for _, err := range err.(*ScatteredError).Errors {
+		var terr *TaskError
+		assert.ErrorAs(t, err, &terr, "Errors are wrapped in a TaskError")
+		assert.Equal(t, 0, terr.Index%2, "The index of the failed task is recorded")
+		assert.Equal(t, "I can't even", err.Error(), "The error message is unchanged")
+	}
+
+	wrapped := &ScatteredError{Errors: []error{&TaskError{Index: 0, Err: &cantEven{}}}}
+	plain := &ScatteredError{Errors: []error{&cantEven{}}}
+	assert.ErrorIs(t, wrapped, plain, "Errors match the task errors they wrap")
+	assert.NotErrorIs(t, plain, wrapped, "Task errors do not match the errors that wrap them")
+}
+
+func TestFlattenErrors(t *testing.T) {
+	sg := New[int](0)
+	sg.FlattenErrors(true)
+	ctx := context.Background()
+	sg.Run(ctx, squareOdds(0))
+	sg.RunNamed(ctx, "nested", func(ctx context.Context) (int, error) {
+		nested := New[int](0)
+		nested.Run(ctx, squareOdds(2))
+		nested.Run(ctx, squareOdds(4))
+		nested.Run(ctx, square(3))
+		results, err := nested.Wait()
+		return results[0], err
+	})
+	_, err := sg.Wait()
+	errs := err.(*ScatteredError).Errors
+	assert.Equal(t, 3, len(errs), "Nested errors are merged")
+	for _, err := range errs {
+		var serr *ScatteredError
+		assert.False(t, errors.As(err, &serr), "No nested ScatteredErrors remain")
+		assert.ErrorIs(t, err, &cantEven{})
+	}
+}
+
+func TestErrorsAs(t *testing.T) {
+	nested := &ScatteredError{}
+	nested.AddError(&TaskError{Index: 0, Err: &cantEven{}})
+	nested.AddError(&TaskError{Index: 1, Err: fmt.Errorf("oops")})
+	e := &ScatteredError{}
+	e.AddError(&TaskError{Index: 0, Err: nested})
+	e.AddError(&TaskError{Index: 1, Err: fmt.Errorf("wrapped: %w", &cantEven{})})
+	e.AddError(&TaskError{Index: 2, Err: context.Canceled})
+	assert.Equal(t, 2, len(ErrorsAs[*cantEven](e)), "Errors are found in nested collections")
+	assert.Equal(t, 3, len(ErrorsAs[*TaskError](e)), "Wrapped errors are not searched")
+	assert.Equal(t, 0, len(ErrorsAs[*TaskPanicError](e)), "No errors are found if none match")
+	assert.Equal(t, 0, len(ErrorsAs[*cantEven](nil)))
+}
+
+func TestScatteredErrorSummary(t *testing.T) {
+	sg := New[int](0)
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		sg.RunNamed(ctx, fmt.Sprintf("task-%d", i), func(context.Context) (int, error) { return squareOdds(i)() })
+	}
+	sg.Run(ctx, func() (int, error) { return 0, fmt.Errorf("oops") })
+	_, err := sg.Wait()
+	assert.Equal(t, map[string]int{"I can't even": 5, "oops": 1}, err.(*ScatteredError).Summary())
+}
+
+func TestErrorFormatting(t *testing.T) {
+	e := &ScatteredError{}
+	for i := 0; i < 5; i++ {
+		e.AddError(fmt.Errorf("error %d", i))
+	}
+	e.Formatting = ErrorFormatting{Separator: "; ", CountPrefix: true, MaxShown: 2}
+	assert.Equal(t, "5 tasks failed: error 0; error 1; ... and 3 more", e.Error())
+	e.Formatting = ErrorFormatting{Separator: ", "}
+	assert.Equal(t, "error 0, error 1, error 2, error 3, error 4", e.Error())
+}
+
+func TestScatteredErrorFormat(t *testing.T) {
+	e := &ScatteredError{}
+	e.AddError(fmt.Errorf("oops"))
+	e.AddError(&TaskError{Index: 1, Err: fmt.Errorf("whoops")})
+	e.AddError(&TaskError{Index: 2, Label: "task", Err: &TaskPanicError{Value: "boom", Stack: []byte("stack trace")}})
+	assert.Equal(t, "oops\nwhoops\ntask: task panicked: boom", fmt.Sprintf("%v", e), "Default formatting is compact")
+	assert.Equal(t, "oops\nwhoops\ntask: task panicked: boom", fmt.Sprintf("%s", e), "String formatting is compact")
+	assert.Equal(t, "3 errors:\noops\ntask 1: whoops\ntask 2 (task): task panicked: boom\nstack trace", fmt.Sprintf("%+v", e), "Verbose formatting shows details")
+}
+
+func TestMaxErrors(t *testing.T) {
+	sg := New[int](0)
+	sg.SetMaxErrors(3)
+	ctx := context.Background()
+	for i := 0; i < 20; i++ {
+		sg.Run(ctx, squareOdds(i))
+	}
+	_, err := sg.Wait()
+	serr := err.(*ScatteredError)
+	assert.Equal(t, 3, len(serr.Errors), "At most 3 errors are stored")
+	assert.Equal(t, 7, serr.Dropped, "Other errors are counted")
+	assert.Equal(t, "I can't even\nI can't even\nI can't even\n... and 7 more errors", err.Error())
+}
+
 func TestBasic(t *testing.T) {
 	sg := new(ScatterGather[int])
 	ctx := context.Background()
@@ -56,6 +187,41 @@ func TestWithErrors(t *testing.T) {
 	assert.Equal(t, expected, result, "We correctly square an array of integers")
 }
 
+func TestJoinErrors(t *testing.T) {
+	sg := New[int](0)
+	sg.JoinErrors(true)
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		sg.Run(ctx, squareOdds(i))
+	}
+	_, err := sg.Wait()
+	_, ok := err.(*ScatteredError)
+	assert.False(t, ok, "No ScatteredError is returned")
+	assert.ErrorIs(t, err, &cantEven{}, "Errors are joined")
+	assert.Equal(t, 2, len(err.(interface{ Unwrap() []error }).Unwrap()), "All errors are joined")
+
+	sg = New[int](0)
+	sg.JoinErrors(true)
+	sg.Run(ctx, square(2))
+	_, err = sg.Wait()
+	assert.Nil(t, err, "No error is returned when all tasks succeed")
+}
+
+func TestExpectTasks(t *testing.T) {
+	sg := New[int](0)
+	sg.ExpectTasks(100)
+	assert.Equal(t, 100, cap(sg.results), "Room for results is allocated")
+	assert.Equal(t, 100, cap(sg.errors.Errors), "Room for errors is allocated")
+	ctx := context.Background()
+	for i := 0; i < 100; i++ {
+		sg.Run(ctx, square(i))
+	}
+	res, _ := sg.Wait()
+	assert.Equal(t, 100, len(res), "All results are returned")
+	sg.Reset()
+	assert.Equal(t, 100, cap(sg.results), "The allocation is kept after Reset")
+}
+
 func TestKeepAllResults(t *testing.T) {
 	sg := New[int](0)
 	sg.KeepAllResults(true)
@@ -80,6 +246,317 @@ func TestKeepAllResults(t *testing.T) {
 	assert.Equal(t, expected, result, "We correctly square an array of integers")
 }
 
+func TestDeduplicateResults(t *testing.T) {
+	sg := New[int](0)
+	Distinct(sg)
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		sg.Run(ctx, func() (int, error) { return i % 3, nil })
+	}
+	res, err := sg.Wait()
+	sort.Ints(res)
+	assert.Equal(t, []int{0, 1, 2}, res, "Duplicate results are dropped")
+	assert.Nil(t, err)
+
+	sg = New[int](0)
+	sg.DeduplicateResults(func(value int) any { return value / 10 })
+	for i := 0; i < 50; i += 5 {
+		sg.Run(ctx, func() (int, error) { return i, nil })
+	}
+	res, _ = sg.Wait()
+	assert.Equal(t, 5, len(res), "Results with duplicate keys are dropped")
+}
+
+func TestDiscardResults(t *testing.T) {
+	sg := New[int](0)
+	sg.DiscardResults(true)
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		sg.Run(ctx, squareOdds(i))
+	}
+	res, err := sg.Wait()
+	assert.Empty(t, res, "No results are returned")
+	assert.Equal(t, 5, len(err.(*ScatteredError).Errors), "Errors are returned")
+}
+
+func TestWaitResults(t *testing.T) {
+	sg := New[int](0)
+	sg.KeepAllResults(true)
+	ctx := context.Background()
+	for i := 0; i < 100; i++ {
+		sg.Run(ctx, squareOdds(i))
+	}
+	results, err := sg.WaitResults()
+	assert.Equal(t, 50, len(err.(*ScatteredError).Errors), "Half the tasks fail")
+	assert.Equal(t, 100, len(results), "All results are returned")
+	for _, res := range results {
+		if res.Index%2 == 0 {
+			assert.ErrorIs(t, res.Err, &cantEven{}, "Even tasks fail")
+		} else {
+			assert.Nil(t, res.Err, "Odd tasks succeed")
+			assert.Equal(t, res.Index*res.Index, res.Value, "Odd tasks are squared")
+		}
+	}
+}
+
+func TestOrderedResults(t *testing.T) {
+	sg := New[int](0)
+	sg.OrderedResults(true)
+	ctx := context.Background()
+	expected := make([]int, 100)
+	for i := range expected {
+		expected[i] = i * i
+		sg.Run(ctx, square(i))
+	}
+	results, err := sg.Wait()
+	assert.Nil(t, err)
+	assert.Equal(t, expected, results, "Results are returned in submission order")
+}
+
+func TestSortResults(t *testing.T) {
+	sg := New[int](0)
+	sg.SortResults(func(a, b int) bool { return a > b })
+	ctx := context.Background()
+	for i := 0; i < 100; i++ {
+		sg.Run(ctx, square(i))
+	}
+	res, err := sg.Wait()
+	assert.Nil(t, err)
+	assert.Equal(t, 100, len(res), "All results are returned")
+	assert.True(t, sort.SliceIsSorted(res, func(i, j int) bool { return res[i] > res[j] }), "Results are sorted")
+}
+
+func TestMergeResults(t *testing.T) {
+	sg := New[map[int]int](0)
+	sg.MergeResults(func(acc, next map[int]int) map[int]int {
+		for k, v := range next {
+			acc[k] = v
+		}
+		return acc
+	})
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		sg.Run(ctx, func() (map[int]int, error) {
+			if i == 5 {
+				return nil, &cantEven{}
+			}
+			return map[int]int{i: i * i}, nil
+		})
+	}
+	res, err := sg.Wait()
+	assert.Equal(t, 1, len(res), "Results are merged into one")
+	assert.Equal(t, 9, len(res[0]), "All results are merged")
+	assert.Equal(t, 81, res[0][9])
+	assert.Equal(t, 1, len(err.(*ScatteredError).Errors), "Errors are returned")
+}
+
+func TestStreamResults(t *testing.T) {
+	sg := New[int](0)
+	ctx := context.Background()
+	stream := sg.Results()
+	go func() {
+		for i := 0; i < 100; i++ {
+			sg.Run(ctx, squareOdds(i))
+		}
+		sg.Wait()
+	}()
+	successes, failures := 0, 0
+	for res := range stream {
+		if res.Err != nil {
+			failures++
+		} else {
+			assert.Equal(t, res.Index*res.Index, res.Value, "Results are streamed with their index")
+			successes++
+		}
+	}
+	assert.Equal(t, 50, successes, "All successes are streamed")
+	assert.Equal(t, 50, failures, "All failures are streamed")
+	assert.Equal(t, 50, len(sg.Err().(*ScatteredError).Errors), "The aggregated error is available")
+}
+
+func TestOnResultOnError(t *testing.T) {
+	sg := New[int](0)
+	ctx := context.Background()
+	sum, failures := 0, 0
+	sg.OnResult(func(value int) { sum += value })
+	sg.OnError(func(err error) {
+		assert.ErrorIs(t, err, &cantEven{}, "Errors are passed to OnError")
+		failures++
+	})
+	for i := 0; i < 10; i++ {
+		sg.Run(ctx, squareOdds(i))
+	}
+	res, err := sg.Wait()
+	assert.Equal(t, 1+9+25+49+81, sum, "Values are passed to OnResult")
+	assert.Equal(t, 5, failures, "All errors are passed to OnError")
+	assert.Equal(t, 5, len(res), "Results are still gathered")
+	assert.Equal(t, 5, len(err.(*ScatteredError).Errors), "Errors are still gathered")
+}
+
+func TestStream(t *testing.T) {
+	sg := New[int](0)
+	ctx := context.Background()
+	for i := 0; i < 100; i++ {
+		sg.Run(ctx, squareOdds(i))
+	}
+	results := make([]int, 0)
+	failures := 0
+	for val, err := range sg.Stream() {
+		if err != nil {
+			failures++
+		} else {
+			results = append(results, val)
+		}
+	}
+	assert.Equal(t, 50, len(results), "All successes are returned")
+	assert.Equal(t, 50, failures, "All failures are returned")
+	assert.Equal(t, 50, len(sg.Err().(*ScatteredError).Errors), "The aggregated error is available")
+
+	sg = New[int](0)
+	for i := 0; i < 10; i++ {
+		sg.Run(ctx, squareOdds(i))
+	}
+	for {
+		gathered, errs, _ := sg.snapshot()
+		if len(gathered)+len(errs.Errors) == 10 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	results, failures = results[:0], 0
+	for val, err := range sg.Stream() {
+		if err != nil {
+			failures++
+		} else {
+			results = append(results, val)
+		}
+	}
+	assert.ElementsMatch(t, []int{1, 9, 25, 49, 81}, results, "Results gathered before streaming are returned")
+	assert.Equal(t, 5, failures, "Failures gathered before streaming are returned")
+}
+
+func TestStreamBreak(t *testing.T) {
+	sg := New[int](1)
+	ctx := context.Background()
+	for i := 0; i < 100; i++ {
+		sg.RunContext(ctx, func(ctx context.Context) (int, error) {
+			select {
+			case <-time.After(time.Second):
+				return i, nil
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+		})
+	}
+	start := time.Now()
+	for range sg.Stream() {
+		break
+	}
+	err := sg.Err()
+	assert.Less(t, time.Since(start), 5*time.Second, "Remaining tasks are cancelled")
+	assert.Equal(t, 99, len(err.(*ScatteredError).Errors), "All other tasks are cancelled")
+}
+
+func TestRecoverPanics(t *testing.T) {
+	sg := New[int](0)
+	sg.RecoverPanics(true)
+	ctx := context.Background()
+	sg.Run(ctx, square(2))
+	sg.Run(ctx, func() (int, error) { panic("oops") })
+	sg.Run(ctx, func() (int, error) { panic(&cantEven{}) })
+	results, err := sg.Wait()
+	assert.Equal(t, []int{4}, results, "Tasks that didn't panic return results")
+	errs := err.(*ScatteredError).Errors
+	assert.Equal(t, 2, len(errs), "Panics are turned into errors")
+	assert.ErrorIs(t, err, &cantEven{}, "Panicked errors are unwrapped")
+	var perr *TaskPanicError
+	assert.ErrorAs(t, errs[0], &perr)
+	assert.Contains(t, string(perr.Stack), "TestRecoverPanics", "The stack trace is recorded")
+}
+
+func TestTaskTimeout(t *testing.T) {
+	sg := New[int](1)
+	sg.SetTaskTimeout(100 * time.Millisecond)
+	ctx := context.Background()
+	sg.RunContext(ctx, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	sg.RunContext(ctx, func(ctx context.Context) (int, error) {
+		time.Sleep(150 * time.Millisecond)
+		return 1, ctx.Err()
+	})
+	sg.Run(ctx, square(2))
+	results, err := sg.Wait()
+	assert.Equal(t, []int{4}, results, "Fast tasks are not affected")
+	errs := err.(*ScatteredError).Errors
+	assert.Equal(t, 2, len(errs), "Slow tasks time out")
+	assert.ErrorIs(t, errs[0], context.DeadlineExceeded)
+	assert.ErrorIs(t, errs[1], context.DeadlineExceeded)
+}
+
+func TestStartJitter(t *testing.T) {
+	sg := New[time.Time](100)
+	sg.SetStartJitter(200 * time.Millisecond)
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		sg.Run(ctx, func() (time.Time, error) { return time.Now(), nil })
+	}
+	results, err := sg.Wait()
+	assert.Nil(t, err)
+	first, last := results[0], results[0]
+	for _, res := range results {
+		if res.Before(first) {
+			first = res
+		}
+		if res.After(last) {
+			last = res
+		}
+	}
+	assert.Greater(t, last.Sub(first), 100*time.Millisecond, "Task starts are spread out")
+	assert.Less(t, last.Sub(start), time.Second, "Task starts are delayed by at most the jitter")
+}
+
+func TestRetry(t *testing.T) {
+	sg := New[int](0)
+	sg.SetRetry(3, time.Millisecond)
+	ctx := context.Background()
+	var attempts [2]atomic.Int32
+	for i := range attempts {
+		sg.Run(ctx, func() (int, error) {
+			if attempts[i].Add(1) < int32(3+2*i) {
+				return 0, &cantEven{}
+			}
+			return i, nil
+		})
+	}
+	results, err := sg.Wait()
+	assert.Equal(t, []int{0}, results, "Tasks that succeed within the retry limit return results")
+	assert.ErrorIs(t, err, &ScatteredError{Errors: []error{&cantEven{}}}, "Tasks that keep failing return the last error")
+	assert.Equal(t, int32(3), attempts[0].Load(), "Tasks are retried until they succeed")
+	assert.Equal(t, int32(4), attempts[1].Load(), "Tasks are retried at most the configured number of times")
+}
+
+func TestRunNamed(t *testing.T) {
+	sg := New[int](0)
+	sg.KeepAllResults(true)
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		sg.RunNamed(ctx, fmt.Sprintf("task-%d", i), func(context.Context) (int, error) { return squareOdds(i)() })
+	}
+	results, err := sg.WaitResults()
+	for _, res := range results {
+		assert.Equal(t, fmt.Sprintf("task-%d", res.Index), res.Label, "Results carry the task label")
+	}
+	for _, err := range err.(*ScatteredError).Errors {
+		var terr *TaskError
+		assert.ErrorAs(t, err, &terr, "Errors are wrapped in a TaskError")
+		assert.Equal(t, terr.Label+": I can't even", err.Error(), "Error messages contain the task label")
+		assert.ErrorIs(t, err, &cantEven{}, "The original error is wrapped")
+	}
+}
+
 func square(i int) func() (int, error) {
 	return func() (int, error) { return i * i, nil }
 }
@@ -140,6 +617,60 @@ func semTester(s *semaphore.Weighted) func() (int, error) {
 	}
 }
 
+type ctxKey struct{}
+
+func TestNewWithFactor(t *testing.T) {
+	procs := int64(runtime.GOMAXPROCS(0))
+	assert.Equal(t, 4*procs, NewWithFactor[int](4).parallel, "Parallelism is a multiple of GOMAXPROCS")
+	assert.Equal(t, int64(1), NewWithFactor[int](0.001).parallel, "Parallelism is at least 1")
+}
+
+func TestTrackGOMAXPROCS(t *testing.T) {
+	procs := runtime.GOMAXPROCS(0)
+	defer runtime.GOMAXPROCS(procs)
+	sg := New[int](1)
+	sg.TrackGOMAXPROCS(true)
+	ctx := context.Background()
+	sg.Run(ctx, square(1))
+	assert.Equal(t, int64(procs), sg.parallel, "Parallelism is set to GOMAXPROCS")
+	runtime.GOMAXPROCS(procs + 2)
+	sg.Run(ctx, square(2))
+	assert.Equal(t, int64(procs+2), sg.parallel, "Changes to GOMAXPROCS are tracked")
+	sg.Wait()
+}
+
+func TestUnlimited(t *testing.T) {
+	sg := New[int](Unlimited)
+	ctx := context.Background()
+	started := make(chan struct{})
+	var running atomic.Int32
+	for i := 0; i < 1000; i++ {
+		sg.Run(ctx, func() (int, error) {
+			if running.Add(1) == 1000 {
+				close(started)
+			}
+			<-started
+			return i, nil
+		})
+	}
+	res, err := sg.Wait()
+	assert.Nil(t, err)
+	assert.Equal(t, 1000, len(res), "All tasks run in parallel")
+}
+
+func TestRunContext(t *testing.T) {
+	sg := New[int](0)
+	ctx := context.WithValue(context.Background(), ctxKey{}, 42)
+	for i := 0; i < 10; i++ {
+		sg.RunContext(ctx, func(ctx context.Context) (int, error) {
+			return ctx.Value(ctxKey{}).(int), nil
+		})
+	}
+	results, err := sg.Wait()
+	assert.Nil(t, err)
+	assert.Equal(t, []int{42, 42, 42, 42, 42, 42, 42, 42, 42, 42}, results, "The context is passed to all tasks")
+}
+
 func TestCanceledContext(t *testing.T) {
 	sg := New[int](3)
 	ctx, cancel := context.WithCancel(context.Background())
@@ -153,6 +684,595 @@ func TestCanceledContext(t *testing.T) {
 	assert.Equal(t, "context canceled", err.(*ScatteredError).Errors[0].Error())
 }
 
+func TestFailFast(t *testing.T) {
+	sg := New[int](101)
+	sg.FailFast(true)
+	ctx := context.Background()
+	sg.Run(ctx, squareOdds(2))
+	for i := 0; i < 100; i++ {
+		sg.RunContext(ctx, func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		})
+	}
+	results, err := sg.Wait()
+	assert.Equal(t, 0, len(results), "No results returned")
+	assert.ErrorIs(t, err.(*ScatteredError).Errors[0], &cantEven{}, "The first error is the original failure")
+	assert.Equal(t, 101, len(err.(*ScatteredError).Errors), "All other tasks are cancelled")
+}
+
+func TestWithContext(t *testing.T) {
+	sg, ctx := WithContext[int](context.Background(), 0)
+	sg.Run(ctx, square(2))
+	results, err := sg.Wait()
+	assert.Nil(t, err)
+	assert.Equal(t, []int{4}, results)
+	assert.ErrorIs(t, ctx.Err(), context.Canceled, "The context is cancelled when Wait returns")
+
+	sg, ctx = WithContext[int](context.Background(), 0)
+	sg.Run(ctx, squareOdds(2))
+	<-ctx.Done()
+	sg.RunContext(ctx, func(ctx context.Context) (int, error) { return 0, ctx.Err() })
+	_, err = sg.Wait()
+	assert.Equal(t, 2, len(err.(*ScatteredError).Errors), "The context is cancelled when a task fails")
+}
+
+func TestMaxPending(t *testing.T) {
+	sg := New[int](1)
+	sg.SetMaxPending(2)
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		sg.Run(ctx, sleepTest(i))
+	}
+	assert.Equal(t, 1000*time.Millisecond, time.Since(start).Truncate(100*time.Millisecond), "Run blocks while too many tasks are pending")
+	results, err := sg.Wait()
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(results), "All tasks are executed")
+}
+
+func TestRunAfter(t *testing.T) {
+	sg := New[time.Time](1)
+	ctx := context.Background()
+	start := time.Now()
+	sg.RunAfter(ctx, 200*time.Millisecond, func() (time.Time, error) { return time.Now(), nil })
+	sg.Run(ctx, func() (time.Time, error) { return time.Now(), nil })
+	results, err := sg.Wait()
+	assert.Nil(t, err)
+	sort.Slice(results, func(i, j int) bool { return results[i].Before(results[j]) })
+	assert.Less(t, results[0].Sub(start), 100*time.Millisecond, "Delayed tasks do not occupy a slot")
+	assert.GreaterOrEqual(t, results[1].Sub(start), 200*time.Millisecond, "The task is delayed")
+
+	sg = New[time.Time](1)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	sg.RunAfter(ctx, time.Hour, func() (time.Time, error) { return time.Now(), nil })
+	_, err = sg.Wait()
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "The task fails when its context is done during the delay")
+}
+
+func TestRunWithFallbacks(t *testing.T) {
+	sg := New[string](0)
+	ctx := context.Background()
+	var tried atomic.Int32
+	primary := func() (string, error) { tried.Add(1); return "", errors.New("primary down") }
+	backup := func() (string, error) { tried.Add(1); return "backup", nil }
+	unused := func() (string, error) { tried.Add(1); return "unused", nil }
+	task := sg.RunWithFallbacks(ctx, primary, backup, unused)
+	value, err := task.Result()
+	assert.Nil(t, err)
+	assert.Equal(t, "backup", value, "The first function to succeed provides the result")
+	assert.Equal(t, int32(2), tried.Load(), "Functions after a success are not tried")
+
+	failing := func() (string, error) { return "", errors.New("backup down") }
+	task = sg.RunWithFallbacks(ctx, primary, failing)
+	_, err = task.Result()
+	var fallbackErr *FallbackError
+	assert.ErrorAs(t, err, &fallbackErr)
+	assert.Len(t, fallbackErr.Errors, 2, "All errors are recorded")
+	assert.EqualError(t, err, "all fallbacks failed: primary down, backup down")
+	_, err = sg.Wait()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "all fallbacks failed")
+}
+
+func TestRunAll(t *testing.T) {
+	sg := New[int](2)
+	ctx := context.Background()
+	tasks := sg.RunAll(ctx, square(1), square(2), square(3))
+	assert.Len(t, tasks, 3, "A handle is returned for every function")
+	value, _ := tasks[2].Result()
+	assert.Equal(t, 9, value, "Handles are in the order of the functions")
+	tasks = RunEach(ctx, sg, []int{4, 5}, func(ctx context.Context, n int) (int, error) { return n * n, nil })
+	value, _ = tasks[0].Result()
+	assert.Equal(t, 16, value, "Handles are in the order of the inputs")
+	results, err := sg.Wait()
+	assert.Nil(t, err)
+	sort.Ints(results)
+	assert.Equal(t, []int{1, 4, 9, 16, 25}, results)
+}
+
+func TestRunFromChannel(t *testing.T) {
+	sg := New[int](2)
+	ctx := context.Background()
+	fns := make(chan func() (int, error))
+	go func() {
+		for i := 1; i <= 3; i++ {
+			fns <- square(i)
+		}
+		close(fns)
+	}()
+	assert.Nil(t, sg.RunFromChannel(ctx, fns), "Functions are taken until the channel is closed")
+	inputs := make(chan int, 2)
+	inputs <- 4
+	inputs <- 5
+	close(inputs)
+	assert.Nil(t, RunEachFromChannel(ctx, sg, inputs, func(ctx context.Context, n int) (int, error) { return n * n, nil }))
+	results, err := sg.Wait()
+	assert.Nil(t, err)
+	sort.Ints(results)
+	assert.Equal(t, []int{1, 4, 9, 16, 25}, results, "All received tasks are run")
+
+	sg = New[int](2)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	err = sg.RunFromChannel(ctx, make(chan func() (int, error)))
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "Taking functions stops when the context is done")
+}
+
+func TestMinDuration(t *testing.T) {
+	sg := New[int](1)
+	sg.SetMinDuration(200 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	var ran atomic.Int32
+	for i := 0; i < 3; i++ {
+		sg.RunContext(ctx, func(ctx context.Context) (int, error) {
+			ran.Add(1)
+			time.Sleep(200 * time.Millisecond)
+			return i, nil
+		})
+	}
+	sg.Run(context.Background(), square(3))
+	results, err := sg.Wait()
+	assert.Equal(t, int32(2), ran.Load(), "Tasks that cannot finish in time are not started")
+	assert.Equal(t, 3, len(results), "Tasks without a deadline are started")
+	assert.ErrorIs(t, err, ErrDeadlineSkipped)
+}
+
+func TestQueueTimeout(t *testing.T) {
+	sg := New[int](1)
+	sg.SetQueueTimeout(100 * time.Millisecond)
+	ctx := context.Background()
+	sg.TryRun(ctx, sleepTest(1))
+	sg.Run(ctx, square(2))
+	results, err := sg.Wait()
+	assert.Equal(t, []int{1}, results, "The running task is not affected")
+	assert.ErrorIs(t, err, ErrQueueTimeout, "Tasks waiting too long for a slot fail")
+}
+
+func TestTryRun(t *testing.T) {
+	sg := New[int](2)
+	ctx := context.Background()
+	accepted := 0
+	for i := 0; i < 5; i++ {
+		if sg.TryRun(ctx, sleepTest(i)) {
+			accepted++
+		}
+	}
+	assert.Equal(t, 2, accepted, "Only tasks that can start immediately are accepted")
+	results, err := sg.Wait()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(results), "Accepted tasks are executed")
+}
+
+func TestRunWeighted(t *testing.T) {
+	sg := New[int](2)
+	ctx := context.Background()
+	s := semaphore.NewWeighted(1)
+	for i := 0; i < 3; i++ {
+		sg.RunWeighted(ctx, 2, semTester(s))
+	}
+	results, err := sg.Wait()
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 1, 1}, results, "Heavy tasks do not run concurrently")
+	assert.PanicsWithValue(t, "scattergather: negative task weight", func() { sg.RunWeighted(ctx, -1, square(1)) })
+}
+
+func TestWaitContext(t *testing.T) {
+	sg := New[int](1)
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		sg.Run(ctx, sleepTest(i))
+	}
+	wctx, cancel := context.WithTimeout(ctx, 1200*time.Millisecond)
+	defer cancel()
+	results, err := sg.WaitContext(wctx)
+	assert.Equal(t, 2, len(results), "Partial results are returned")
+	var ierr *IncompleteError
+	assert.ErrorAs(t, err.(*ScatteredError).Errors[0], &ierr)
+	assert.Equal(t, 3, ierr.Outstanding, "The number of outstanding tasks is returned")
+	assert.ErrorIs(t, ierr, context.DeadlineExceeded, "The context error is wrapped")
+	results, err = sg.Wait()
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(results), "Wait can be called afterwards")
+}
+
+func TestWaitAppend(t *testing.T) {
+	buf := make([]int, 0, 100)
+	ctx := context.Background()
+	for round := 0; round < 2; round++ {
+		sg := New[int](0)
+		sg.OrderedResults(true)
+		for i := 0; i < 10; i++ {
+			sg.Run(ctx, square(i))
+		}
+		res, err := sg.WaitAppend(buf[:0])
+		assert.Nil(t, err)
+		assert.Equal(t, []int{0, 1, 4, 9, 16, 25, 36, 49, 64, 81}, res, "Results are appended")
+		assert.Same(t, &buf[:1][0], &res[0], "The buffer is reused")
+	}
+	res, _ := New[int](0).WaitAppend([]int{1})
+	assert.Equal(t, []int{1}, res, "Existing elements are kept")
+}
+
+func TestWaitFlat(t *testing.T) {
+	sg := New[[]int](0)
+	sg.OrderedResults(true)
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		sg.Run(ctx, func() ([]int, error) {
+			if i == 2 {
+				return nil, &cantEven{}
+			}
+			return []int{i, i * i}, nil
+		})
+	}
+	res, err := WaitFlat(sg)
+	assert.Equal(t, []int{0, 0, 1, 1, 3, 9}, res, "All slices are concatenated")
+	assert.Equal(t, 1, len(err.(*ScatteredError).Errors), "Errors are returned")
+}
+
+func TestWaitN(t *testing.T) {
+	sg := New[int](4)
+	ctx := context.Background()
+	sg.Run(ctx, squareOdds(2))
+	sg.Run(ctx, square(3))
+	sg.Run(ctx, square(4))
+	sg.RunContext(ctx, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	results, err := sg.WaitN(2)
+	assert.Nil(t, err, "No error is returned when enough tasks succeed")
+	sort.Ints(results)
+	assert.Equal(t, []int{9, 16}, results, "The successful results are returned")
+
+	sg = New[int](0)
+	sg.Run(ctx, squareOdds(2))
+	sg.Run(ctx, square(3))
+	results, err = sg.WaitN(2)
+	assert.Equal(t, []int{9}, results, "The successful results are returned")
+	assert.ErrorIs(t, err, &ScatteredError{Errors: []error{&cantEven{}}}, "Errors are returned when not enough tasks succeed")
+}
+
+func TestLimit(t *testing.T) {
+	sg := New[int](101)
+	sg.Limit(5)
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		sg.Run(ctx, squareOdds(i))
+	}
+	for i := 0; i < 90; i++ {
+		sg.RunContext(ctx, func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		})
+	}
+	results, err := sg.Wait()
+	assert.Nil(t, err, "No error is returned when enough tasks succeed")
+	sort.Ints(results)
+	assert.Equal(t, []int{1, 9, 25, 49, 81}, results, "The successful results are returned")
+
+	sg = New[int](0)
+	sg.Limit(5)
+	sg.Run(ctx, squareOdds(2))
+	sg.Run(ctx, square(3))
+	results, err = sg.Wait()
+	assert.Equal(t, []int{9}, results, "The successful results are returned")
+	assert.ErrorIs(t, err, &cantEven{}, "Errors are returned when not enough tasks succeed")
+}
+
+func TestWaitFirst(t *testing.T) {
+	sg := New[int](4)
+	ctx := context.Background()
+	sg.Run(ctx, squareOdds(2))
+	sg.Run(ctx, square(3))
+	sg.RunContext(ctx, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	result, err := sg.WaitFirst()
+	assert.Nil(t, err, "No error is returned when a task succeeds")
+	assert.Equal(t, 9, result, "The successful result is returned")
+
+	sg = New[int](4)
+	sg.Run(ctx, squareOdds(2))
+	sg.Run(ctx, squareOdds(4))
+	result, err = sg.WaitFirst()
+	assert.Equal(t, 0, result)
+	assert.Equal(t, 2, len(err.(*ScatteredError).Errors), "All errors are returned when all tasks fail")
+}
+
+func TestHedgeDelay(t *testing.T) {
+	sg := New[int](2)
+	sg.SetHedgeDelay(100 * time.Millisecond)
+	ctx := context.Background()
+	var attempts atomic.Int32
+	sg.RunContext(ctx, func(ctx context.Context) (int, error) {
+		if attempts.Add(1) == 1 {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		return 1, nil
+	})
+	start := time.Now()
+	results, err := sg.Wait()
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1}, results, "The result of the duplicate is used")
+	assert.Equal(t, int32(2), attempts.Load(), "A duplicate is started")
+	assert.Less(t, time.Since(start), time.Second, "The slow task is cancelled")
+}
+
+func TestCollapseCancellations(t *testing.T) {
+	sg := New[int](101)
+	sg.FailFast(true)
+	sg.CollapseCancellations(true)
+	ctx := context.Background()
+	sg.Run(ctx, squareOdds(2))
+	for i := 0; i < 100; i++ {
+		sg.RunContext(ctx, func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		})
+	}
+	_, err := sg.Wait()
+	serr := err.(*ScatteredError)
+	assert.Equal(t, 2, len(serr.Errors), "Cancellation errors are collapsed")
+	assert.Equal(t, 99, serr.Cancelled, "Cancelled tasks are counted")
+	assert.ErrorIs(t, err, &cantEven{}, "The original error is kept")
+	assert.ErrorIs(t, err, context.Canceled, "One cancellation error is kept")
+	assert.Contains(t, err.Error(), "... and 99 more tasks cancelled")
+}
+
+func TestMaxFailures(t *testing.T) {
+	sg := New[int](1)
+	sg.SetMaxFailures(3)
+	ctx := context.Background()
+	var last *Task[int]
+	for i := 0; i < 100; i++ {
+		last = sg.Run(ctx, squareOdds(i))
+	}
+	results, err := sg.Wait()
+	serr := err.(*ScatteredError)
+	assert.Equal(t, 4, len(serr.Errors), "Tasks stop running after 3 failures")
+	var skerr *SkippedError
+	assert.ErrorAs(t, err, &skerr, "Skipped tasks are reported")
+	assert.ErrorIs(t, err, ErrTooManyFailures)
+	assert.Equal(t, 100, skerr.Skipped+len(results)+3, "All tasks are accounted for")
+	_, err = last.Result()
+	assert.Equal(t, &SkippedError{Skipped: 1, Err: ErrTooManyFailures}, err, "The handle of a skipped task reports that it was skipped")
+
+	sg = New[int](1)
+	sg.SetMaxFailures(1)
+	limiter := &countingLimiter{}
+	sg.SetRateLimit(limiter)
+	start := time.Now()
+	// Take the only slot right away, so this task fails before any other starts
+	release := make(chan struct{})
+	sg.TryRun(ctx, func() (int, error) {
+		<-release
+		return 0, errors.New("failed")
+	})
+	sg.RunAfter(ctx, time.Hour, square(2))
+	var ran atomic.Int32
+	for i := 0; i < 5; i++ {
+		sg.Run(ctx, func() (int, error) {
+			ran.Add(1)
+			return i, nil
+		})
+	}
+	close(release)
+	_, err = sg.Wait()
+	assert.Less(t, time.Since(start), time.Second, "Waiting tasks stop waiting")
+	assert.ErrorAs(t, err, &skerr)
+	assert.Equal(t, 6, skerr.Skipped, "Waiting tasks are skipped")
+	assert.Equal(t, int32(0), ran.Load(), "No task takes the slot of the failed task")
+	assert.Equal(t, int32(1), limiter.calls.Load(), "Skipped tasks do not use the rate limiter")
+}
+
+type countingLimiter struct {
+	calls atomic.Int32
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	l.calls.Add(1)
+	return nil
+}
+
+func TestReset(t *testing.T) {
+	sg := New[int](0)
+	sg.OrderedResults(true)
+	sg.SetMaxErrors(1)
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		sg.Run(ctx, squareOdds(i))
+	}
+	results, err := sg.Wait()
+	assert.Equal(t, []int{1, 9}, results)
+	assert.Equal(t, 2, err.(*ScatteredError).Dropped)
+	sg.Reset()
+	for i := 5; i < 10; i++ {
+		sg.Run(ctx, squareOdds(i))
+	}
+	results, err2 := sg.Wait()
+	assert.Equal(t, []int{25, 49, 81}, results, "Only the results of the new batch are returned, with the same options")
+	assert.Equal(t, 1, err2.(*ScatteredError).Dropped, "Only the errors of the new batch are returned, with the same options")
+	assert.Equal(t, 2, err.(*ScatteredError).Dropped, "Errors of the previous batch are not affected")
+}
+
+func TestSnapshot(t *testing.T) {
+	sg := New[int](1)
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		sg.Run(ctx, sleepTest(i))
+	}
+	assert.Equal(t, 0, len(sg.Snapshot()), "No results are available yet")
+	time.Sleep(1200 * time.Millisecond)
+	assert.Equal(t, 2, len(sg.Snapshot()), "Results gathered so far are returned")
+	results, err := sg.Wait()
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(results), "The group is not terminated")
+	assert.Equal(t, 5, len(sg.Snapshot()), "Snapshot still works after Wait")
+}
+
+func TestWaitTwice(t *testing.T) {
+	sg := New[int](0)
+	sg.OrderedResults(true)
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		sg.Run(ctx, squareOdds(i))
+	}
+	results, err := sg.Wait()
+	results2, err2 := sg.Wait()
+	assert.Equal(t, []int{1, 9}, results)
+	assert.Equal(t, results, results2, "The same results are returned")
+	assert.Equal(t, err, err2, "The same error is returned")
+}
+
+func TestRunAfterWait(t *testing.T) {
+	sg := New[int](0)
+	ctx := context.Background()
+	sg.Run(ctx, square(2))
+	sg.Wait()
+	assert.PanicsWithValue(t, ErrAlreadyFinished, func() { sg.Run(ctx, square(1)) }, "Tasks are rejected after Wait")
+	sg.Reset()
+	sg.Run(ctx, square(3))
+	results, _ := sg.Wait()
+	assert.Equal(t, []int{9}, results, "Tasks are accepted after Reset")
+}
+
+func TestCancelPending(t *testing.T) {
+	sg := New[int](1)
+	ctx := context.Background()
+	started, release := make(chan struct{}), make(chan struct{})
+	sg.Run(ctx, func() (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	var ran atomic.Int32
+	for i := 0; i < 5; i++ {
+		sg.Run(ctx, func() (int, error) {
+			ran.Add(1)
+			return i, nil
+		})
+	}
+	sg.CancelPending()
+	sg.Run(ctx, func() (int, error) {
+		ran.Add(1)
+		return 0, nil
+	})
+	close(release)
+	results, err := sg.Wait()
+	assert.Equal(t, []int{1}, results, "Running tasks finish")
+	assert.Equal(t, int32(0), ran.Load(), "Pending tasks are not run")
+	assert.Equal(t, 6, len(err.(*ScatteredError).Errors), "Pending tasks fail")
+	assert.ErrorIs(t, err, ErrPendingCancelled)
+
+	sg.Reset()
+	sg.Run(ctx, square(2))
+	results, err = sg.Wait()
+	assert.Nil(t, err)
+	assert.Equal(t, []int{4}, results, "Tasks run again after Reset")
+}
+
+func TestAbort(t *testing.T) {
+	sg := New[int](2)
+	sg.OrderedResults(true)
+	ctx := context.Background()
+	sg.Run(ctx, square(1))
+	for sg.Progress().Succeeded == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	sg.RunContext(ctx, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	sg.Run(ctx, func() (int, error) {
+		time.Sleep(time.Hour)
+		return 0, nil
+	})
+	for sg.Progress().Running < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	var ran atomic.Int32
+	for i := 0; i < 5; i++ {
+		sg.Run(ctx, func() (int, error) {
+			ran.Add(1)
+			return i, nil
+		})
+	}
+	reason := errors.New("shutting down")
+	start := time.Now()
+	sg.Abort(reason)
+	results, err := sg.Wait()
+	assert.Less(t, time.Since(start), time.Second, "Wait returns promptly")
+	assert.Equal(t, []int{1}, results[:1], "Partial results are returned")
+	assert.Equal(t, int32(0), ran.Load(), "Queued tasks are not run")
+	assert.ErrorIs(t, err, reason, "The abort reason is returned")
+	var incomplete *IncompleteError
+	assert.ErrorAs(t, err, &incomplete)
+
+	sg = New[int](2)
+	sg.Run(ctx, func() (int, error) {
+		time.Sleep(100 * time.Millisecond)
+		return 1, nil
+	})
+	sg.Abort(reason)
+	sg.Wait()
+	sg.Reset()
+	sg.Run(ctx, square(2))
+	results, err = sg.Wait()
+	assert.Nil(t, err)
+	assert.Equal(t, []int{4}, results, "Tasks of an aborted batch don't end up in the next one")
+}
+
+func TestClose(t *testing.T) {
+	sg := New[int](1)
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		sg.RunContext(ctx, func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		})
+	}
+	sg.Close()
+	sg.Close()
+	assert.PanicsWithValue(t, ErrClosed, func() { sg.Run(ctx, square(1)) }, "Tasks are rejected after Close")
+
+	sg = New[int](1)
+	sg.Run(ctx, square(2))
+	results, err := sg.Wait()
+	sg.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, []int{4}, results, "Close can be called after Wait")
+
+	sg = New[int](1)
+	sg.Close()
+}
+
 func TestSetParallel(t *testing.T) {
 	start := time.Now()
 	sg := New[int](0)
@@ -169,3 +1289,26 @@ func TestSetParallel(t *testing.T) {
 	assert.Equal(t, 100, len(results), "We have 100 results")
 	assert.Equal(t, end.Sub(start).Truncate(100*time.Millisecond), 1600*time.Millisecond, "We ran in 1.6 seconds")
 }
+
+func TestSetParallelShrink(t *testing.T) {
+	sg := New[int](4)
+	ctx := context.Background()
+	release := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		sg.Run(ctx, func() (int, error) {
+			<-release
+			return i, nil
+		})
+	}
+	for sg.Slots().InUse < 4 {
+		time.Sleep(time.Millisecond)
+	}
+	sg.SetParallel(2)
+	assert.Equal(t, Slots{Size: 2, InUse: 4, Waiting: 4}, sg.Slots(), "Running tasks are not interrupted")
+	close(release)
+	results, err := sg.Wait()
+	assert.Nil(t, err)
+	assert.Equal(t, 8, len(results), "All tasks are run")
+	sg.SetParallel(0)
+	assert.Equal(t, int64(runtime.GOMAXPROCS(0)), sg.Slots().Size, "A maximum of 0 means GOMAXPROCS")
+}
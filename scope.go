@@ -0,0 +1,51 @@
+package scattergather
+
+import (
+	"context"
+)
+
+// A scope in which tasks are spawned by the function passed to Scoped. All of
+// them are finished before Scoped returns.
+type Scope[T any] struct {
+	sg  *ScatterGather[T]
+	ctx context.Context
+}
+
+// Run fn with a new Scope, with at most parallel of the tasks spawned in it
+// running in parallel, and return the results and aggregated error of all
+// those tasks once they have finished. When parallel is 0, the maximum is set
+// to GOMAXPROCS. This makes it impossible to forget to wait for tasks.
+//
+// When fn returns an error or panics, the context of all tasks is cancelled
+// and they are waited for before Scoped returns the error of fn, with the
+// results gathered so far, or resumes panicking.
+func Scoped[T any](ctx context.Context, parallel int64, fn func(s *Scope[T]) error) ([]T, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s := &Scope[T]{sg: New[T](parallel), ctx: ctx}
+	defer func() {
+		if r := recover(); r != nil {
+			cancel()
+			s.sg.Wait()
+			panic(r)
+		}
+	}()
+	if err := fn(s); err != nil {
+		cancel()
+		results, _ := s.sg.Wait()
+		return results, err
+	}
+	return s.sg.Wait()
+}
+
+// Spawn a task in the scope. Its context is cancelled when the context passed
+// to Scoped is done, or when the function passed to Scoped fails. Tasks must
+// be spawned before that function returns.
+func (s *Scope[T]) Go(callable func(context.Context) (T, error)) *Task[T] {
+	return s.sg.RunContext(s.ctx, callable)
+}
+
+// The context of the scope, which is cancelled once Scoped returns
+func (s *Scope[T]) Context() context.Context {
+	return s.ctx
+}
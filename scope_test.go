@@ -0,0 +1,53 @@
+package scattergather
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoped(t *testing.T) {
+	results, err := Scoped(context.Background(), 0, func(s *Scope[int]) error {
+		for i := 0; i < 4; i++ {
+			s.Go(func(ctx context.Context) (int, error) { return i * i, nil })
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	sort.Ints(results)
+	assert.Equal(t, []int{0, 1, 4, 9}, results, "All tasks are waited for")
+
+	var finished atomic.Int32
+	started := make(chan struct{})
+	_, err = Scoped(context.Background(), 2, func(s *Scope[int]) error {
+		s.Go(func(ctx context.Context) (int, error) {
+			close(started)
+			<-ctx.Done()
+			finished.Add(1)
+			return 0, ctx.Err()
+		})
+		<-started
+		return errors.New("failed")
+	})
+	assert.EqualError(t, err, "failed", "The error of the function is returned")
+	assert.Equal(t, int32(1), finished.Load(), "Tasks are cancelled and waited for")
+
+	started = make(chan struct{})
+	assert.PanicsWithValue(t, "boom", func() {
+		Scoped(context.Background(), 2, func(s *Scope[int]) error {
+			s.Go(func(ctx context.Context) (int, error) {
+				close(started)
+				<-ctx.Done()
+				finished.Add(1)
+				return 0, nil
+			})
+			<-started
+			panic("boom")
+		})
+	})
+	assert.Equal(t, int32(2), finished.Load(), "Tasks are cancelled and waited for on panic")
+}
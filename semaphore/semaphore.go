@@ -0,0 +1,233 @@
+// A weighted semaphore that can be resized while in use
+//
+// The semaphore is fair: waiters acquire it in the order in which they
+// started waiting, so a waiter for a large weight is not starved by a stream
+// of waiters for smaller weights. Waiters that request more than the size of
+// the semaphore do not hold up the waiters behind them, and are served when
+// the semaphore grows large enough.
+package semaphore
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// A semaphore with a maximum combined weight for concurrent access
+type Weighted struct {
+	lock    sync.Mutex
+	size    int64
+	cur     int64
+	waiters list.List
+}
+
+type waiter struct {
+	n     int64
+	ready chan struct{}
+}
+
+// Create a new semaphore with the given maximum combined weight
+func NewWeighted(n int64) *Weighted {
+	return &Weighted{size: n}
+}
+
+// Acquire the semaphore with a weight of n, blocking until enough weight is
+// available or ctx is done. On failure, ctx.Err() is returned and the
+// semaphore is left unchanged. If ctx is already done, Acquire may still
+// succeed without blocking. A negative weight panics.
+func (s *Weighted) Acquire(ctx context.Context, n int64) error {
+	return s.Reserve(n).Wait(ctx)
+}
+
+// A place in line to acquire a semaphore, as returned by Reserve
+type Reservation struct {
+	s    *Weighted
+	n    int64
+	w    *waiter
+	elem *list.Element
+}
+
+// Take a place in line to acquire the semaphore with a weight of n, without
+// blocking. This acquires the semaphore right away if it can, like
+// TryAcquire, and otherwise makes sure that callers that start waiting later
+// are not served first. Wait for the reservation to find out when the
+// semaphore has been acquired, or Cancel it if it is no longer needed. A
+// negative weight panics.
+func (s *Weighted) Reserve(n int64) *Reservation {
+	checkWeight(n)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.size-s.cur >= n && !s.queued() {
+		s.cur += n
+		return &Reservation{s: s, n: n}
+	}
+	w := &waiter{n: n, ready: make(chan struct{})}
+	return &Reservation{s: s, n: n, w: w, elem: s.waiters.PushBack(w)}
+}
+
+// Wait until the semaphore has been acquired, or until ctx is done. On
+// failure, ctx.Err() is returned, the place in line is given up and the
+// semaphore is left unchanged, as with Acquire.
+func (r *Reservation) Wait(ctx context.Context) error {
+	if r.w == nil {
+		return nil
+	}
+	select {
+	case <-r.w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+	if !r.leave() {
+		// Acquired after ctx was done. Rather than undoing that, pretend we
+		// didn't notice ctx being done.
+		return nil
+	}
+	return ctx.Err()
+}
+
+// Give up a reservation that will not be waited for. If the semaphore was
+// already acquired for it, it is released.
+func (r *Reservation) Cancel() {
+	if !r.leave() {
+		r.s.Release(r.n)
+	}
+}
+
+// Leave the line, unless the semaphore has already been acquired. Returns
+// whether the reservation left the line.
+func (r *Reservation) leave() bool {
+	if r.w == nil {
+		return false
+	}
+	s := r.s
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	select {
+	case <-r.w.ready:
+		return false
+	default:
+	}
+	s.waiters.Remove(r.elem)
+	// The waiters behind this one may fit now
+	s.notifyWaiters()
+	return true
+}
+
+// Acquire the semaphore with a weight of n without blocking. Returns whether
+// the semaphore was acquired. A negative weight panics.
+func (s *Weighted) TryAcquire(n int64) bool {
+	checkWeight(n)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.size-s.cur >= n && !s.queued() {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+// Release the semaphore with a weight of n. Releasing more than is held, or a
+// negative weight, panics.
+func (s *Weighted) Release(n int64) {
+	checkWeight(n)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.cur < n {
+		panic("semaphore: released more than held")
+	}
+	s.cur -= n
+	s.notifyWaiters()
+}
+
+// Change the maximum combined weight. When the semaphore grows, waiters that
+// now fit are woken up in order. When it shrinks below the weight currently
+// acquired, holders are not affected, but no new acquisitions succeed until
+// enough weight has been released to fit below the new size.
+func (s *Weighted) Resize(n int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.size = n
+	s.notifyWaiters()
+}
+
+// Change the maximum combined weight like Resize, but only if it does not
+// shrink below the weight currently acquired. Returns whether the semaphore
+// was resized.
+func (s *Weighted) TryResize(n int64) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if n < s.cur {
+		return false
+	}
+	s.size = n
+	s.notifyWaiters()
+	return true
+}
+
+// Change the maximum combined weight like Resize.
+//
+// Deprecated: use Resize.
+func (s *Weighted) SetSize(n int64) {
+	s.Resize(n)
+}
+
+// Returns the maximum combined weight
+func (s *Weighted) Size() int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.size
+}
+
+// Returns the combined weight currently acquired
+func (s *Weighted) InUse() int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.cur
+}
+
+// Returns the number of callers waiting in Acquire, including reservations
+// that have not been served yet
+func (s *Weighted) Waiters() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.waiters.Len()
+}
+
+// Weights are never negative, as that would let callers grow the semaphore
+func checkWeight(n int64) {
+	if n < 0 {
+		panic("semaphore: negative weight")
+	}
+}
+
+// Whether a waiter that fits in the semaphore is waiting, which new callers
+// must not overtake
+func (s *Weighted) queued() bool {
+	for elem := s.waiters.Front(); elem != nil; elem = elem.Next() {
+		if elem.Value.(*waiter).n <= s.size {
+			return true
+		}
+	}
+	return false
+}
+
+// Wake up waiters in order, as long as they fit. Waiters that could never fit
+// at the current size are passed over, so they don't block those behind them.
+func (s *Weighted) notifyWaiters() {
+	for elem := s.waiters.Front(); elem != nil; {
+		w := elem.Value.(*waiter)
+		if w.n > s.size {
+			elem = elem.Next()
+			continue
+		}
+		if s.size-s.cur < w.n {
+			// Don't let smaller waiters jump the queue, or large ones starve
+			return
+		}
+		s.cur += w.n
+		close(w.ready)
+		next := elem.Next()
+		s.waiters.Remove(elem)
+		elem = next
+	}
+}
@@ -0,0 +1,168 @@
+package semaphore
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Wait until n callers are waiting in Acquire
+func waitForWaiters(s *Weighted, n int) {
+	for s.Waiters() < n {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWeighted(t *testing.T) {
+	s := NewWeighted(2)
+	ctx := context.Background()
+	assert.Nil(t, s.Acquire(ctx, 1))
+	assert.True(t, s.TryAcquire(1))
+	assert.False(t, s.TryAcquire(1), "The semaphore is full")
+	assert.Equal(t, int64(2), s.InUse())
+	s.Release(2)
+	assert.Equal(t, int64(0), s.InUse())
+	assert.Panics(t, func() { s.Release(1) }, "Releasing more than is held panics")
+	assert.Panics(t, func() { s.Acquire(ctx, -1) }, "Negative weights panic")
+	assert.Panics(t, func() { s.TryAcquire(-1) }, "Negative weights panic")
+	assert.Panics(t, func() { s.Reserve(-1) }, "Negative weights panic")
+	assert.Panics(t, func() { s.Release(-1) }, "Negative weights panic")
+	assert.Equal(t, int64(2), s.Size(), "The semaphore is left unchanged")
+}
+
+func TestAcquireCancel(t *testing.T) {
+	s := NewWeighted(1)
+	s.Acquire(context.Background(), 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.Acquire(ctx, 1), context.DeadlineExceeded)
+	assert.Equal(t, 0, s.Waiters(), "Cancelled waiters stop waiting")
+	assert.Equal(t, int64(1), s.InUse(), "The semaphore is left unchanged")
+}
+
+func TestReserve(t *testing.T) {
+	s := NewWeighted(1)
+	ctx := context.Background()
+	first := s.Reserve(1)
+	second := s.Reserve(1)
+	third := s.Reserve(1)
+	assert.Equal(t, 2, s.Waiters(), "Reservations that cannot be served wait in line")
+	assert.False(t, s.TryAcquire(1), "Reservations are not overtaken")
+	assert.Nil(t, first.Wait(ctx), "A reservation that could be served right away is acquired")
+	second.Cancel()
+	assert.Equal(t, 1, s.Waiters(), "Cancelled reservations leave the line")
+	s.Release(1)
+	assert.Nil(t, third.Wait(ctx), "The next reservation in line is served")
+	third.Cancel()
+	assert.Equal(t, int64(0), s.InUse(), "Cancelling a served reservation releases it")
+
+	s.Acquire(ctx, 1)
+	waiting := s.Reserve(1)
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, waiting.Wait(timeout), context.DeadlineExceeded)
+	assert.Equal(t, 0, s.Waiters(), "A reservation whose wait failed leaves the line")
+}
+
+func TestFairness(t *testing.T) {
+	s := NewWeighted(3)
+	ctx := context.Background()
+	s.Acquire(ctx, 2)
+	done := make(chan struct{})
+	go func() {
+		s.Acquire(ctx, 3)
+		close(done)
+	}()
+	waitForWaiters(s, 1)
+	assert.False(t, s.TryAcquire(1), "Small acquisitions don't overtake large waiters")
+	s.Release(2)
+	<-done
+	assert.Equal(t, int64(3), s.InUse())
+}
+
+func TestOversizedWaiter(t *testing.T) {
+	s := NewWeighted(2)
+	ctx := context.Background()
+	done := make(chan struct{})
+	go func() {
+		s.Acquire(ctx, 3)
+		close(done)
+	}()
+	waitForWaiters(s, 1)
+	assert.True(t, s.TryAcquire(2), "Oversized waiters don't block others")
+	s.Resize(5)
+	<-done
+	assert.Equal(t, int64(5), s.InUse(), "Oversized waiters are served when the semaphore grows")
+}
+
+func TestResize(t *testing.T) {
+	s := NewWeighted(4)
+	ctx := context.Background()
+	s.Acquire(ctx, 4)
+	s.Resize(2)
+	assert.Equal(t, int64(4), s.InUse(), "Holders are not affected by shrinking")
+	s.Release(2)
+	assert.False(t, s.TryAcquire(1), "No acquisitions succeed while in use is not below the size")
+	s.Release(1)
+	assert.True(t, s.TryAcquire(1))
+
+	done := make(chan struct{})
+	go func() {
+		s.Acquire(ctx, 2)
+		close(done)
+	}()
+	waitForWaiters(s, 1)
+	s.Resize(4)
+	<-done
+	assert.Equal(t, int64(4), s.InUse(), "Waiters are woken up when the semaphore grows")
+
+	s.SetSize(6)
+	assert.Equal(t, int64(6), s.Size(), "SetSize still resizes")
+}
+
+func TestTryResize(t *testing.T) {
+	s := NewWeighted(4)
+	s.Acquire(context.Background(), 3)
+	assert.False(t, s.TryResize(2), "The semaphore does not shrink below the weight in use")
+	assert.Equal(t, int64(4), s.Size())
+	assert.True(t, s.TryResize(3))
+	assert.Equal(t, int64(3), s.Size())
+}
+
+func TestConcurrentResize(t *testing.T) {
+	s := NewWeighted(5)
+	ctx := context.Background()
+	wg := sync.WaitGroup{}
+	lock := sync.Mutex{}
+	held, peak := 0, 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				s.Acquire(ctx, 1)
+				lock.Lock()
+				held++
+				peak = max(peak, held)
+				lock.Unlock()
+				time.Sleep(time.Microsecond)
+				lock.Lock()
+				held--
+				lock.Unlock()
+				s.Release(1)
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		s.Resize(int64(1 + i%10))
+		time.Sleep(100 * time.Microsecond)
+	}
+	s.Resize(10)
+	wg.Wait()
+	assert.LessOrEqual(t, peak, 10, "The semaphore is never exceeded")
+	assert.Equal(t, int64(0), s.InUse())
+	assert.Equal(t, 0, s.Waiters())
+}
@@ -0,0 +1,82 @@
+package scattergather
+
+import (
+	"bufio"
+	"encoding/gob"
+	"errors"
+	"io"
+	"iter"
+	"os"
+)
+
+// A Gatherer that writes values to a temporary file as they arrive, using
+// encoding/gob, so that very large sets of results do not have to be kept in
+// memory. Count is the number of values written. The values can be read back
+// with All once Wait() has returned, and the file is removed by Close.
+type Spill[T any] struct {
+	Count   int
+	file    *os.File
+	writer  *bufio.Writer
+	encoder *gob.Encoder
+	err     error
+}
+
+// Create a new Spill gatherer that writes to a new temporary file in dir. When
+// dir is empty, the default directory for temporary files is used.
+func NewSpill[T any](dir string) (*Spill[T], error) {
+	file, err := os.CreateTemp(dir, "scattergather-*")
+	if err != nil {
+		return nil, err
+	}
+	writer := bufio.NewWriter(file)
+	return &Spill[T]{file: file, writer: writer, encoder: gob.NewEncoder(writer)}, nil
+}
+
+func (s *Spill[T]) OnResult(value T) {
+	if s.err != nil {
+		return
+	}
+	if s.err = s.encoder.Encode(&value); s.err == nil {
+		s.Count++
+	}
+}
+
+func (s *Spill[T]) OnError(error) {}
+
+// Flush the values to disk, and return the first error that occurred while
+// writing them
+func (s *Spill[T]) Finish() error {
+	if s.err == nil {
+		s.err = s.writer.Flush()
+	}
+	return s.err
+}
+
+// Iterate over the values that were written, in the order in which they
+// arrived. Iteration stops at the first error reading them back, which is
+// yielded with a zero value.
+func (s *Spill[T]) All() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var value T
+		if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+			yield(value, err)
+			return
+		}
+		decoder := gob.NewDecoder(bufio.NewReader(s.file))
+		for i := 0; i < s.Count; i++ {
+			var value T
+			if err := decoder.Decode(&value); err != nil {
+				yield(value, err)
+				return
+			}
+			if !yield(value, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Close and remove the temporary file
+func (s *Spill[T]) Close() error {
+	return errors.Join(s.file.Close(), os.Remove(s.file.Name()))
+}
@@ -0,0 +1,38 @@
+package scattergather
+
+import (
+	"context"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpill(t *testing.T) {
+	sg := New[int](0)
+	spill, err := NewSpill[int](t.TempDir())
+	assert.Nil(t, err)
+	sg.SetGatherer(spill)
+	ctx := context.Background()
+	for i := 0; i < 1000; i++ {
+		sg.Run(ctx, squareOdds(i))
+	}
+	res, err := sg.Wait()
+	assert.Empty(t, res, "Results are not kept in memory")
+	assert.Equal(t, 500, len(err.(*ScatteredError).Errors), "Errors are returned")
+	assert.Equal(t, 500, spill.Count, "All values are written")
+	values := make([]int, 0)
+	for value, err := range spill.All() {
+		assert.Nil(t, err)
+		values = append(values, value)
+	}
+	sort.Ints(values)
+	assert.Equal(t, 500, len(values), "All values are read back")
+	assert.Equal(t, 1, values[0])
+	assert.Equal(t, 999*999, values[499])
+	name := spill.file.Name()
+	assert.Nil(t, spill.Close())
+	_, err = os.Stat(name)
+	assert.True(t, os.IsNotExist(err), "The file is removed")
+}
@@ -0,0 +1,48 @@
+package scattergather
+
+// The numeric types Stats can summarize
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// A Gatherer that summarizes numeric values instead of collecting them. Use it
+// with ScatterGather.SetGatherer, or pass its Add method to
+// ScatterGather.OnResult to summarize values while still collecting them.
+type Stats[T Number] struct {
+	Count int
+	Sum   T
+	Min   T
+	Max   T
+}
+
+// Add a value to the summary
+func (s *Stats[T]) Add(value T) {
+	if s.Count == 0 || value < s.Min {
+		s.Min = value
+	}
+	if s.Count == 0 || value > s.Max {
+		s.Max = value
+	}
+	s.Count++
+	s.Sum += value
+}
+
+// Returns the mean of all values, or 0 if there are none
+func (s *Stats[T]) Mean() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Sum) / float64(s.Count)
+}
+
+func (s *Stats[T]) OnResult(value T) {
+	s.Add(value)
+}
+
+func (s *Stats[T]) OnError(error) {}
+
+func (s *Stats[T]) Finish() error {
+	return nil
+}
@@ -0,0 +1,33 @@
+package scattergather
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	sg := New[int](0)
+	stats := &Stats[int]{}
+	sg.SetGatherer(stats)
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		sg.Run(ctx, squareOdds(i))
+	}
+	_, err := sg.Wait()
+	assert.Equal(t, 5, len(err.(*ScatteredError).Errors), "Errors are returned")
+	assert.Equal(t, Stats[int]{Count: 5, Sum: 165, Min: 1, Max: 81}, *stats, "Values are summarized")
+	assert.Equal(t, 33.0, stats.Mean())
+
+	sg = New[int](0)
+	stats = &Stats[int]{}
+	sg.OnResult(stats.Add)
+	for i := 0; i < 10; i++ {
+		sg.Run(ctx, square(i))
+	}
+	res, _ := sg.Wait()
+	assert.Equal(t, 10, len(res), "Values are still collected")
+	assert.Equal(t, Stats[int]{Count: 10, Sum: 285, Min: 0, Max: 81}, *stats, "Values are summarized")
+	assert.Equal(t, 0.0, (&Stats[float64]{}).Mean(), "The mean of no values is 0")
+}
@@ -0,0 +1,113 @@
+package scattergather
+
+import (
+	"context"
+)
+
+// A handle to a single submitted task, as returned by Run and its variants
+type Task[T any] struct {
+	done   chan struct{}
+	cancel context.CancelFunc
+	result Result[T]
+}
+
+// Create the handle for a task, giving the task a context of its own so it
+// can be cancelled separately
+func newTask[T any](t *task[T]) *Task[T] {
+	handle := &Task[T]{done: make(chan struct{})}
+	t.ctx, handle.cancel = context.WithCancel(t.ctx)
+	return handle
+}
+
+// Record the outcome of the task and wake up everyone waiting for it
+func (t *Task[T]) finish(res Result[T]) {
+	t.result = res
+	close(t.done)
+	t.cancel()
+}
+
+// Returns a channel that is closed when the task has completed
+func (t *Task[T]) Done() <-chan struct{} {
+	return t.done
+}
+
+// Wait for the task to complete, and return the value and error it returned.
+// Like for Wait(), errors are wrapped in a *TaskError. A task that was skipped
+// because too many tasks failed returns a *SkippedError.
+func (t *Task[T]) Result() (T, error) {
+	<-t.done
+	return t.result.Value, t.result.Err
+}
+
+// Cancel the context of this task. If it has not started yet, it will not be
+// started at all.
+func (t *Task[T]) Cancel() {
+	t.cancel()
+}
+
+// Wait for the dependencies of a task to complete, or until ctx is done, and
+// return a *SkippedError if any of them failed
+func awaitDependencies[T any](ctx context.Context, deps []*Task[T]) error {
+	for _, dep := range deps {
+		select {
+		case <-dep.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if dep.result.Err != nil {
+			return &SkippedError{Skipped: 1, Err: dep.result.Err}
+		}
+	}
+	return nil
+}
+
+// Wait for all of the given tasks to complete, and return their values in the
+// order in which the tasks were passed. The returned error is nil if no task
+// failed, or a *ScatteredError with the errors of the tasks that did.
+func AwaitAll[T any](tasks ...*Task[T]) ([]T, error) {
+	values := make([]T, len(tasks))
+	errs := &ScatteredError{}
+	for i, t := range tasks {
+		var err error
+		values[i], err = t.Result()
+		if err != nil {
+			errs.AddError(err)
+		}
+	}
+	if !errs.HasErrors() {
+		return values, nil
+	}
+	return values, errs
+}
+
+// Wait for any of the given tasks to complete, and return it. When no tasks
+// are given, nil is returned.
+func AwaitAny[T any](tasks ...*Task[T]) *Task[T] {
+	if len(tasks) == 0 {
+		return nil
+	}
+	for _, t := range tasks {
+		select {
+		case <-t.done:
+			return t
+		default:
+		}
+	}
+	// Fan in with a goroutine per task, which works for any number of tasks
+	first := make(chan *Task[T], 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	for _, t := range tasks {
+		go func() {
+			select {
+			case <-t.done:
+				select {
+				case first <- t:
+				default:
+				}
+			case <-stop:
+			}
+		}()
+	}
+	return <-first
+}
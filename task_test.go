@@ -0,0 +1,108 @@
+package scattergather
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTask(t *testing.T) {
+	sg := New[int](4)
+	ctx := context.Background()
+	release := make(chan struct{})
+	first := sg.Run(ctx, square(3))
+	second := sg.Run(ctx, func() (int, error) {
+		<-release
+		return 0, nil
+	})
+	third := sg.RunContext(ctx, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	value, err := first.Result()
+	assert.Nil(t, err)
+	assert.Equal(t, 9, value, "The result of a single task is available")
+	select {
+	case <-second.Done():
+		t.Error("Task is done before it returned")
+	default:
+	}
+	third.Cancel()
+	_, err = third.Result()
+	assert.ErrorIs(t, err, context.Canceled, "Tasks can be cancelled separately")
+	close(release)
+	<-second.Done()
+	results, err := sg.Wait()
+	assert.Equal(t, 2, len(results), "Results are still gathered")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestAwait(t *testing.T) {
+	sg := New[int](4)
+	ctx := context.Background()
+	release := make(chan struct{})
+	slow := sg.Run(ctx, func() (int, error) {
+		<-release
+		return 1, nil
+	})
+	fast := sg.Run(ctx, square(2))
+	assert.Same(t, fast, AwaitAny(slow, fast), "The first task to complete is returned")
+	assert.Nil(t, AwaitAny[int](), "Nothing is returned without tasks")
+	gate := make(chan struct{})
+	later := sg.Run(ctx, func() (int, error) {
+		<-gate
+		return 3, nil
+	})
+	many := make([]*Task[int], 70000)
+	for i := range many {
+		many[i] = slow
+	}
+	many[len(many)-1] = later
+	time.AfterFunc(10*time.Millisecond, func() { close(gate) })
+	assert.Same(t, later, AwaitAny(many...), "Any number of tasks can be awaited")
+	close(release)
+	failed := sg.Run(ctx, squareOdds(4))
+	values, err := AwaitAll(slow, fast)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 4}, values, "Values are returned in order")
+	values, err = AwaitAll(fast, failed)
+	assert.Equal(t, []int{4, 0}, values)
+	assert.Equal(t, 1, len(err.(*ScatteredError).Errors), "Errors are returned")
+	sg.Wait()
+}
+
+func TestRunAfterTasks(t *testing.T) {
+	sg := New[int](4)
+	sg.KeepAllResults(true)
+	ctx := context.Background()
+	lock := sync.Mutex{}
+	var order []int
+	step := func(i int, err error) func() (int, error) {
+		return func() (int, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			order = append(order, i)
+			return i, err
+		}
+	}
+	release := make(chan struct{})
+	first := sg.Run(ctx, func() (int, error) {
+		<-release
+		return step(1, nil)()
+	})
+	second := sg.Run(ctx, step(2, nil))
+	third := sg.RunAfterTasks(ctx, step(3, nil), first, second)
+	failed := sg.RunAfterTasks(ctx, step(4, &cantEven{}), third)
+	skipped := sg.RunAfterTasks(ctx, step(5, nil), failed)
+	<-second.Done()
+	close(release)
+	sg.Wait()
+	assert.Equal(t, []int{2, 1, 3, 4}, order, "Tasks run after their dependencies")
+	_, err := skipped.Result()
+	var skip *SkippedError
+	assert.ErrorAs(t, err, &skip, "Tasks whose dependencies fail are skipped")
+	assert.ErrorIs(t, err, &cantEven{}, "The error of the dependency is wrapped")
+}
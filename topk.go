@@ -0,0 +1,67 @@
+package scattergather
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// A Gatherer that keeps only the k largest values, according to less, instead
+// of all of them. To keep the k smallest values, pass a less function that
+// reports whether a is greater than b.
+type TopK[T any] struct {
+	k    int
+	heap topHeap[T]
+}
+
+// Create a new TopK gatherer that keeps the k largest values. Use it with
+// ScatterGather.SetGatherer, and get the values with Results once Wait()
+// has returned.
+func NewTopK[T any](k int, less func(a, b T) bool) *TopK[T] {
+	return &TopK[T]{k: k, heap: topHeap[T]{values: make([]T, 0, k), less: less}}
+}
+
+func (g *TopK[T]) OnResult(value T) {
+	if g.k <= 0 {
+		return
+	}
+	if len(g.heap.values) < g.k {
+		heap.Push(&g.heap, value)
+	} else if g.heap.less(g.heap.values[0], value) {
+		g.heap.values[0] = value
+		heap.Fix(&g.heap, 0)
+	}
+}
+
+func (g *TopK[T]) OnError(error) {}
+
+func (g *TopK[T]) Finish() error {
+	return nil
+}
+
+// Returns the values that were kept, largest first
+func (g *TopK[T]) Results() []T {
+	values := make([]T, len(g.heap.values))
+	copy(values, g.heap.values)
+	sort.SliceStable(values, func(i, j int) bool { return g.heap.less(values[j], values[i]) })
+	return values
+}
+
+// A min-heap, so the smallest of the values kept can be replaced
+type topHeap[T any] struct {
+	values []T
+	less   func(a, b T) bool
+}
+
+func (h topHeap[T]) Len() int           { return len(h.values) }
+func (h topHeap[T]) Less(i, j int) bool { return h.less(h.values[i], h.values[j]) }
+func (h topHeap[T]) Swap(i, j int)      { h.values[i], h.values[j] = h.values[j], h.values[i] }
+
+func (h *topHeap[T]) Push(x any) {
+	h.values = append(h.values, x.(T))
+}
+
+func (h *topHeap[T]) Pop() any {
+	last := h.values[len(h.values)-1]
+	h.values = h.values[:len(h.values)-1]
+	return last
+}
@@ -0,0 +1,30 @@
+package scattergather
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopK(t *testing.T) {
+	sg := New[int](0)
+	top := NewTopK(3, func(a, b int) bool { return a < b })
+	sg.SetGatherer(top)
+	ctx := context.Background()
+	for i := 0; i < 100; i++ {
+		sg.Run(ctx, squareOdds(i))
+	}
+	_, err := sg.Wait()
+	assert.Equal(t, 50, len(err.(*ScatteredError).Errors), "Errors are returned")
+	assert.Equal(t, []int{99 * 99, 97 * 97, 95 * 95}, top.Results(), "The largest values are kept")
+
+	sg = New[int](0)
+	bottom := NewTopK(3, func(a, b int) bool { return a > b })
+	sg.SetGatherer(bottom)
+	for i := 0; i < 100; i++ {
+		sg.Run(ctx, square(i))
+	}
+	sg.Wait()
+	assert.Equal(t, []int{0, 1, 4}, bottom.Results(), "The smallest values are kept")
+}